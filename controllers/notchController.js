@@ -304,6 +304,7 @@ var NotchController = class NotchController {
         this.notificationManager.addCallback((info) => this._onNotificationReceived(info));
         this.windowManager.addCallback((info) => this._onWindowLaunched(info));
         this.recordingManager.addCallback((info) => this._onRecordingChanged(info));
+        this.recordingManager.addLevelCallback((peak) => this.recordingView.setLevel(peak));
         this.cameraManager.addCallback((info) => this._onCameraChanged(info));
         this.uxplayManager.addCallback((info) => this._onUxplayChanged(info));
     }
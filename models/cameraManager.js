@@ -5,6 +5,7 @@ var CameraManager = class CameraManager {
         this._callbacks = [];
         this._isCameraInUse = false;
         this._appName = '';
+        this._cameraName = '';
         this._startTime = null;
         this._serverProxy = null;
         this._destroyed = false;
@@ -80,6 +81,7 @@ var CameraManager = class CameraManager {
         if (eventType === 'camera_start') {
             this._isCameraInUse = true;
             this._appName = appName || metadataObj.app_name || 'Camera';
+            this._cameraName = metadataObj.camera || '';
             this._startTime = Date.now();
         } else {
             this._isCameraInUse = false;
@@ -90,6 +92,7 @@ var CameraManager = class CameraManager {
         const info = {
             isCameraInUse: this._isCameraInUse,
             appName: this._appName,
+            cameraName: this._cameraName,
             startTime: this._startTime
         };
         this._notifyCallbacks(info);
@@ -129,6 +132,7 @@ var CameraManager = class CameraManager {
         return {
             isCameraInUse: true,
             appName: this._appName,
+            cameraName: this._cameraName,
             elapsedSeconds: elapsed,
             startTime: this._startTime
         };
@@ -3,6 +3,8 @@ const Gio = imports.gi.Gio;
 var MediaManager = class MediaManager {
     constructor() {
         this._callbacks = [];
+        this._spectrumCallbacks = [];
+        this._spectrumActive = false;
         this._serverProxy = null;
         this._methodsProxy = null;
         this._playbackStatus = null;
@@ -34,6 +36,12 @@ var MediaManager = class MediaManager {
                         <arg name="timestamp" type="s" direction="out"/>
                         <arg name="metadata" type="s" direction="out"/>
                     </signal>
+                    <signal name="Spectrum">
+                        <arg name="bands" type="ad" direction="out"/>
+                    </signal>
+                    <method name="SetSpectrumActive">
+                        <arg name="active" type="b" direction="in"/>
+                    </method>
                     <method name="MediaNext">
                     </method>
                     <method name="MediaPrevious">
@@ -68,6 +76,11 @@ var MediaManager = class MediaManager {
                     this._onServerEvent(eventType, appName, pid, timestamp, metadata);
                 });
 
+                // Band levels of what the output is playing, for the visualizer
+                this._serverProxy.connectSignal('Spectrum', (proxy, senderName, [bands]) => {
+                    this._notifySpectrum(bands);
+                });
+
                 // Đánh dấu đã khởi tạo xong sau một khoảng thời gian ngắn
                 imports.mainloop.timeout_add(500, () => {
                     this._isInitializing = false;
@@ -85,6 +98,11 @@ var MediaManager = class MediaManager {
                 if (error) {
                     // log(`[DynamicIsland] MediaManager: Failed to connect methods proxy: ${error.message || error}`);
                 } else {
+                    // Resend a request made before the proxy was ready
+                    if (this._spectrumActive) {
+                        this._sendSpectrumActive(true);
+                    }
+
                     // Fetch initial media state
                     this._methodsProxy.GetMediaInfoRemote((result, error) => {
                         if (error || !result) {
@@ -202,7 +220,9 @@ var MediaManager = class MediaManager {
         // Update state
         this._currentMetadata = this._createMetadataObject(metadataObj);
         this._playbackStatus = status;
-        this._currentArtPath = metadataObj.artUrl || '';
+        // Prefer the server-rendered thumbnail, GJS decodes it much faster than full covers
+        const thumbnails = metadataObj.thumbnails || {};
+        this._currentArtPath = thumbnails.large || metadataObj.artUrl || '';
         this._currentPlayer = player;
 
         // Notify callbacks
@@ -378,6 +398,58 @@ var MediaManager = class MediaManager {
         }
     }
 
+    /**
+     * Add callback for spectrum frames
+     * @param {Function} callback - Called with an array of band levels (0-1)
+     */
+    addSpectrumCallback(callback) {
+        if (typeof callback === 'function') {
+            this._spectrumCallbacks.push(callback);
+        }
+    }
+
+    /**
+     * Ask the server to send spectrum frames while a visualizer is visible
+     * @param {boolean} active - Whether a visualizer is on screen
+     */
+    setSpectrumActive(active) {
+        if (this._spectrumActive === active) {
+            return;
+        }
+        this._spectrumActive = active;
+        this._sendSpectrumActive(active);
+    }
+
+    _sendSpectrumActive(active) {
+        if (!this._methodsProxy) {
+            return;
+        }
+
+        try {
+            this._methodsProxy.SetSpectrumActiveRemote(active, (result, error) => {
+                if (error) {
+                    // log(`[DynamicIsland] MediaManager: Error setting spectrum state: ${error.message || error}`);
+                }
+            });
+        } catch (e) {
+            // log(`[DynamicIsland] MediaManager: Exception setting spectrum state: ${e.message || e}`);
+        }
+    }
+
+    _notifySpectrum(bands) {
+        if (this._destroyed) {
+            return;
+        }
+
+        this._spectrumCallbacks.forEach(callback => {
+            try {
+                callback(bands);
+            } catch (e) {
+                // log(`[DynamicIsland] MediaManager: Spectrum callback error: ${e.message || e}`);
+            }
+        });
+    }
+
     /**
      * Notify all registered callbacks
      * @param {object} info - Media info object
@@ -412,6 +484,7 @@ var MediaManager = class MediaManager {
      * Clean up and destroy MediaManager
      */
     destroy() {
+        this.setSpectrumActive(false);
         this._destroyed = true;
 
         // Disconnect proxies
@@ -424,5 +497,6 @@ var MediaManager = class MediaManager {
         this._currentArtPath = null;
         this._currentPlayer = null;
         this._callbacks = [];
+        this._spectrumCallbacks = [];
     }
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ const Gio = imports.gi.Gio;
 var RecordingManager = class RecordingManager {
     constructor() {
         this._callbacks = [];
+        this._levelCallbacks = [];
         this._isRecording = false;
         this._appName = '';
         this._serverProxy = null;
@@ -59,6 +60,12 @@ var RecordingManager = class RecordingManager {
 
         // log(`[DynamicIsland] RecordingManager: Received event: ${eventType}, appName: ${appName}`);
 
+        // Live input level while recording (throttled by the server)
+        if (eventType === 'microphone_level') {
+            this._onLevel(metadata);
+            return;
+        }
+
         // Chỉ xử lý các events recording (server emit microphone_start/microphone_stop)
         if (eventType !== 'microphone_start' && eventType !== 'microphone_stop' &&
             eventType !== 'recording_started' && eventType !== 'recording_stopped') {
@@ -97,6 +104,25 @@ var RecordingManager = class RecordingManager {
         this._notifyCallbacks(info);
     }
 
+    _onLevel(metadata) {
+        if (!this._isRecording) return;
+
+        let level;
+        try {
+            level = JSON.parse(metadata);
+        } catch (e) {
+            return;
+        }
+
+        this._levelCallbacks.forEach(callback => {
+            try {
+                callback(level.peak || 0, level.rms || 0);
+            } catch (e) {
+                // log(`[DynamicIsland] RecordingManager: Level callback error: ${e.message || e}`);
+            }
+        });
+    }
+
     _notifyCallbacks(info) {
         this._callbacks.forEach(callback => {
             try {
@@ -118,6 +144,10 @@ var RecordingManager = class RecordingManager {
         }
     }
 
+    addLevelCallback(callback) {
+        this._levelCallbacks.push(callback);
+    }
+
     isRecording() {
         return this._isRecording;
     }
@@ -136,6 +166,7 @@ var RecordingManager = class RecordingManager {
     destroy() {
         this._destroyed = true;
         this._callbacks = [];
+        this._levelCallbacks = [];
         if (this._serverProxy) {
             this._serverProxy = null;
         }
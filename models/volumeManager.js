@@ -178,7 +178,7 @@ var VolumeManager = class VolumeManager {
         if (!this._methodsProxy) return false;
 
         try {
-            const targetVolume = Math.round(Math.max(0, Math.min(120, percentage)));
+            const targetVolume = Math.round(Math.max(0, Math.min(150, percentage)));
 
             // Gọi method SetVolume qua server
             this._methodsProxy.SetVolumeRemote(targetVolume, (result, error) => {
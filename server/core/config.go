@@ -0,0 +1,175 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const configFileName = "server.json"
+
+// Config is the server configuration read from
+// $XDG_CONFIG_HOME/dynamic-island/server.json. Missing keys keep their defaults.
+type Config struct {
+	Media      MediaConfig      `json:"media"`
+	Volume     VolumeConfig     `json:"volume"`
+	Microphone MicrophoneConfig `json:"microphone"`
+	Spectrum   SpectrumConfig   `json:"spectrum"`
+	Privacy    PrivacyConfig    `json:"privacy"`
+}
+
+type MediaConfig struct {
+	LyricsDir     string       `json:"lyricsDir"`
+	RecordHistory bool         `json:"recordHistory"`
+	IgnorePlayers []PlayerRule `json:"ignorePlayers"`
+	AllowPlayers  []PlayerRule `json:"allowPlayers"`
+}
+
+type VolumeConfig struct {
+	// MaxVolume caps the output volume in percent (100-150). Zero follows
+	// GNOME's allow-volume-above-100-percent setting.
+	MaxVolume int `json:"maxVolume"`
+}
+
+type MicrophoneConfig struct {
+	IgnoreApps []MicrophoneRule `json:"ignoreApps"`
+	AllowApps  []MicrophoneRule `json:"allowApps"`
+	// IncludeMonitors counts streams that record an output's monitor, such
+	// as visualizers and level meters, as microphone use.
+	IncludeMonitors bool `json:"includeMonitors"`
+}
+
+// MicrophoneRule matches a capture stream when every non-empty field matches
+// the stream property named in its comment. Fields are case-insensitive
+// globs. An allow rule wins over ignore rules.
+type MicrophoneRule struct {
+	// AppName falls back to the client name when the stream has none.
+	AppName string `json:"appName,omitempty"` // application.name
+	Binary  string `json:"binary,omitempty"`  // application.process.binary
+	Role    string `json:"role,omitempty"`    // media.role
+	Class   string `json:"class,omitempty"`   // media.class
+}
+
+// PrivacyConfig controls the ledger of camera, microphone and screen
+// capture use.
+type PrivacyConfig struct {
+	RecordUsage bool `json:"recordUsage"`
+	// RetentionDays is how long usage sessions are kept; 0 keeps them all.
+	RetentionDays int `json:"retentionDays"`
+}
+
+// SpectrumConfig controls the output spectrum fed to the media visualizer.
+type SpectrumConfig struct {
+	Enabled bool `json:"enabled"`
+	// Bands is the number of frequency bands per frame.
+	Bands int `json:"bands"`
+	// FrameRate is the maximum number of frames per second (up to 60).
+	FrameRate int `json:"frameRate"`
+}
+
+// PlayerRule matches an MPRIS player when every non-empty field matches.
+// String fields are case-insensitive globs; BusName may omit the
+// "org.mpris.MediaPlayer2." prefix. An allow rule wins over ignore rules.
+type PlayerRule struct {
+	BusName      string `json:"busName,omitempty"`
+	Identity     string `json:"identity,omitempty"`
+	DesktopEntry string `json:"desktopEntry,omitempty"`
+	HasMetadata  *bool  `json:"hasMetadata,omitempty"`
+}
+
+func DefaultConfig() *Config {
+	noMetadata := false
+	return &Config{
+		Media: MediaConfig{
+			LyricsDir:     "~/.lyrics",
+			RecordHistory: true,
+			IgnorePlayers: []PlayerRule{
+				// Players that never exposed a track are usually input
+				// devices or idle helpers rather than media apps.
+				{HasMetadata: &noMetadata},
+			},
+		},
+		Microphone: MicrophoneConfig{
+			IgnoreApps: []MicrophoneRule{
+				// Audio processors and system components record
+				// constantly without anyone using the microphone.
+				{AppName: "PulseEffects"},
+				{AppName: "EasyEffects"},
+				{Binary: "easyeffects"},
+				{AppName: "PulseAudio"},
+				{AppName: "PipeWire"},
+				{AppName: "GNOME Shell"},
+				{Binary: "gnome-shell"},
+			},
+		},
+		Spectrum: SpectrumConfig{
+			Enabled:   true,
+			Bands:     6,
+			FrameRate: 25,
+		},
+		Privacy: PrivacyConfig{
+			RecordUsage:   true,
+			RetentionDays: 30,
+		},
+	}
+}
+
+// ConfigDir returns the directory holding the server configuration.
+func ConfigDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "dynamic-island")
+}
+
+// DataDir returns the directory for persistent server state
+// ($XDG_DATA_HOME/dynamic-island).
+func DataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "dynamic-island")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share", "dynamic-island")
+}
+
+// LoadConfig reads the configuration file. A missing file is not an error.
+func LoadConfig() (*Config, error) {
+	cfg := DefaultConfig()
+
+	dir := ConfigDir()
+	if dir == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read config: %v", err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return DefaultConfig(), fmt.Errorf("failed to parse config: %v", err)
+	}
+
+	return cfg, nil
+}
+
+// ExpandPath resolves a leading "~" to the user's home directory.
+func ExpandPath(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
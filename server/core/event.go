@@ -9,20 +9,34 @@ import (
 type EventType string
 
 const (
-	EventMicrophoneStart       EventType = "microphone_start"
-	EventMicrophoneStop        EventType = "microphone_stop"
-	EventCameraStart           EventType = "camera_start"
-	EventCameraStop            EventType = "camera_stop"
-	EventBluetoothConnected    EventType = "bluetooth_connected"
-	EventBluetoothDisconnected EventType = "bluetooth_disconnected"
-	EventNotification          EventType = "notification"
-	EventVolumeChanged         EventType = "volume_changed"
-	EventVolumeMuted           EventType = "volume_muted"
-	EventVolumeUnmuted         EventType = "volume_unmuted"
-	EventBrightnessChanged     EventType = "brightness_changed"
-	EventMediaChanged          EventType = "media_changed"
-	EventBatteryChanged        EventType = "battery_changed"
-	EventUxplaySharing         EventType = "uxplay_sharing"
+	EventMicrophoneStart         EventType = "microphone_start"
+	EventMicrophoneStop          EventType = "microphone_stop"
+	EventMicrophoneMuted         EventType = "microphone_muted"
+	EventMicrophoneUnmuted       EventType = "microphone_unmuted"
+	EventMicrophoneVolumeChanged EventType = "microphone_volume_changed"
+	EventMicrophoneLevel         EventType = "microphone_level"
+	EventCameraStart             EventType = "camera_start"
+	EventCameraStop              EventType = "camera_stop"
+	EventCameraConnected         EventType = "camera_connected"
+	EventCameraDisconnected      EventType = "camera_disconnected"
+	EventScreenShareStart        EventType = "screen_share_start"
+	EventScreenShareStop         EventType = "screen_share_stop"
+	EventBluetoothConnected      EventType = "bluetooth_connected"
+	EventBluetoothDisconnected   EventType = "bluetooth_disconnected"
+	EventNotification            EventType = "notification"
+	EventVolumeChanged           EventType = "volume_changed"
+	EventVolumeMuted             EventType = "volume_muted"
+	EventVolumeUnmuted           EventType = "volume_unmuted"
+	EventAudioOutputChanged      EventType = "audio_output_changed"
+	EventAudioStreamAdded        EventType = "audio_stream_added"
+	EventAudioStreamRemoved      EventType = "audio_stream_removed"
+	EventAudioStreamChanged      EventType = "audio_stream_changed"
+	EventBrightnessChanged       EventType = "brightness_changed"
+	EventMediaChanged            EventType = "media_changed"
+	EventMediaLyric              EventType = "media_lyric"
+	EventMediaTrackListChanged   EventType = "media_tracklist_changed"
+	EventBatteryChanged          EventType = "battery_changed"
+	EventUxplaySharing           EventType = "uxplay_sharing"
 )
 
 type Event struct {
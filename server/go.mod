@@ -5,6 +5,7 @@ go 1.23.0
 require (
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/godbus/dbus/v5 v5.2.0
+	golang.org/x/image v0.25.0
 )
 
 require golang.org/x/sys v0.27.0 // indirect
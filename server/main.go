@@ -2,6 +2,7 @@ package main
 
 import (
 	"dynamic-island-server/core"
+	"dynamic-island-server/modules/audio"
 	"dynamic-island-server/modules/battery"
 	"dynamic-island-server/modules/bluetooth"
 	"dynamic-island-server/modules/brightness"
@@ -10,6 +11,9 @@ import (
 	"dynamic-island-server/modules/media"
 	"dynamic-island-server/modules/microphone"
 	"dynamic-island-server/modules/notification"
+	"dynamic-island-server/modules/privacy"
+	"dynamic-island-server/modules/screenshare"
+	"dynamic-island-server/modules/spectrum"
 	"dynamic-island-server/modules/uxplay"
 	"dynamic-island-server/modules/volume"
 	"fmt"
@@ -31,8 +35,63 @@ const introspectXML = `
 		<method name="SetVolume">
 			<arg name="level" type="i" direction="in"/>
 		</method>
+		<method name="VolumeUp">
+			<arg name="step" type="i" direction="in"/>
+			<arg name="level" type="i" direction="out"/>
+		</method>
+		<method name="VolumeDown">
+			<arg name="step" type="i" direction="in"/>
+			<arg name="level" type="i" direction="out"/>
+		</method>
+		<method name="SetVolumeBalance">
+			<arg name="balance" type="d" direction="in"/>
+		</method>
 		<method name="ToggleMute">
 		</method>
+		<method name="ListAudioOutputs">
+			<arg name="outputs" type="s" direction="out"/>
+		</method>
+		<method name="SetDefaultAudioOutput">
+			<arg name="name" type="s" direction="in"/>
+			<arg name="moveStreams" type="b" direction="in"/>
+		</method>
+		<method name="ListAudioInputs">
+			<arg name="inputs" type="s" direction="out"/>
+		</method>
+		<method name="SetDefaultAudioInput">
+			<arg name="name" type="s" direction="in"/>
+			<arg name="moveStreams" type="b" direction="in"/>
+		</method>
+		<method name="ListAudioStreams">
+			<arg name="streams" type="s" direction="out"/>
+		</method>
+		<method name="SetStreamVolume">
+			<arg name="id" type="u" direction="in"/>
+			<arg name="level" type="i" direction="in"/>
+		</method>
+		<method name="SetStreamMute">
+			<arg name="id" type="u" direction="in"/>
+			<arg name="muted" type="b" direction="in"/>
+		</method>
+		<method name="ToggleMicMute">
+			<arg name="muted" type="b" direction="out"/>
+		</method>
+		<method name="SetMicVolume">
+			<arg name="level" type="i" direction="in"/>
+		</method>
+		<method name="GetMicrophoneDiagnostics">
+			<arg name="streams" type="s" direction="out"/>
+		</method>
+		<method name="GetPrivacyHistory">
+			<arg name="since" type="x" direction="in"/>
+			<arg name="history" type="s" direction="out"/>
+		</method>
+		<method name="GetPrivacySummary">
+			<arg name="summary" type="s" direction="out"/>
+		</method>
+		<method name="SetSpectrumActive">
+			<arg name="active" type="b" direction="in"/>
+		</method>
 		<method name="SetBrightness">
 			<arg name="level" type="i" direction="in"/>
 		</method>
@@ -42,6 +101,12 @@ const introspectXML = `
 		</method>
 		<method name="MediaPlayPause">
 		</method>
+		<method name="MediaSetVolume">
+			<arg name="level" type="i" direction="in"/>
+		</method>
+		<method name="MediaGetVolume">
+			<arg name="level" type="i" direction="out"/>
+		</method>
 		<method name="GetBatteryInfo">
 			<arg name="percentage" type="i" direction="out"/>
 			<arg name="isCharging" type="b" direction="out"/>
@@ -56,6 +121,30 @@ const introspectXML = `
 			<arg name="artist" type="s" direction="out"/>
 			<arg name="artUrl" type="s" direction="out"/>
 		</method>
+		<method name="GetLyrics">
+			<arg name="lyrics" type="s" direction="out"/>
+		</method>
+		<method name="GetRecentTracks">
+			<arg name="count" type="i" direction="in"/>
+			<arg name="tracks" type="s" direction="out"/>
+		</method>
+		<method name="ExportScrobblerLog">
+			<arg name="name" type="s" direction="in"/>
+			<arg name="written" type="s" direction="out"/>
+			<arg name="count" type="i" direction="out"/>
+		</method>
+		<method name="GetTrackList">
+			<arg name="tracks" type="s" direction="out"/>
+		</method>
+		<method name="GoToTrack">
+			<arg name="id" type="s" direction="in"/>
+		</method>
+		<method name="GetPlaylists">
+			<arg name="playlists" type="s" direction="out"/>
+		</method>
+		<method name="ActivatePlaylist">
+			<arg name="id" type="s" direction="in"/>
+		</method>
 		<signal name="EventOccurred">
 			<arg name="event_type" type="s" direction="out"/>
 			<arg name="app_name" type="s" direction="out"/>
@@ -63,6 +152,9 @@ const introspectXML = `
 			<arg name="timestamp" type="s" direction="out"/>
 			<arg name="metadata" type="s" direction="out"/>
 		</signal>
+		<signal name="Spectrum">
+			<arg name="bands" type="ad" direction="out"/>
+		</signal>
 	</interface>
 	<interface name="org.freedesktop.DBus.Introspectable">
 		<method name="Introspect">
@@ -80,9 +172,12 @@ type EventMonitor struct {
 	mediaSource   *media.MediaSource
 	batterySource *battery.BatterySource
 	mediaService  *media.MediaService
+	audioBackend  *audio.Backend
+	spectrum      *spectrum.SpectrumSource
+	privacy       *privacy.PrivacySource
 }
 
-func NewEventMonitor() (*EventMonitor, error) {
+func NewEventMonitor(cfg *core.Config) (*EventMonitor, error) {
 	conn, err := dbus.ConnectSessionBus()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to session bus: %v", err)
@@ -105,15 +200,23 @@ func NewEventMonitor() (*EventMonitor, error) {
 		return nil, fmt.Errorf("failed to export introspection: %v", err)
 	}
 
-	mediaSource := media.NewMediaSource()
+	audioBackend := audio.NewBackend("Dynamic Island")
+	mediaSource := media.NewMediaSource(cfg.Media)
 	batterySource := battery.NewBatterySource()
+	spectrumSource := spectrum.NewSpectrumSource(func(bands []float64) {
+		conn.Emit(objectPath, serviceName+".Spectrum", bands)
+	}, audioBackend, cfg.Spectrum)
+	privacySource := privacy.NewPrivacySource(cfg.Privacy)
 
 	brightnessService := brightness.NewBrightnessService(conn)
-	volumeService := volume.NewVolumeService()
-	mediaService := media.NewMediaService(conn, mediaSource)
+	volumeService := volume.NewVolumeService(audioBackend, cfg.Volume)
+	mediaService := media.NewMediaService(conn, mediaSource, audioBackend)
 	batteryService := battery.NewBatteryService(batterySource)
+	microphoneService := microphone.NewMicrophoneService(audioBackend, cfg.Microphone)
+	spectrumService := spectrum.NewSpectrumService(spectrumSource)
+	privacyService := privacy.NewPrivacyService(privacySource)
 
-	serverMethods := handlers.NewServerMethods(batteryService, brightnessService, volumeService, mediaService)
+	serverMethods := handlers.NewServerMethods(batteryService, brightnessService, volumeService, mediaService, microphoneService, spectrumService, privacyService)
 	if err := conn.Export(serverMethods, objectPath, serviceName); err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("failed to export methods: %v", err)
@@ -127,6 +230,9 @@ func NewEventMonitor() (*EventMonitor, error) {
 		mediaSource:   mediaSource,
 		batterySource: batterySource,
 		mediaService:  mediaService,
+		audioBackend:  audioBackend,
+		spectrum:      spectrumSource,
+		privacy:       privacySource,
 	}
 
 	return m, nil
@@ -158,6 +264,7 @@ func (m *EventMonitor) Stop() {
 }
 
 func (m *EventMonitor) Close() {
+	m.audioBackend.Close()
 	if m.conn != nil {
 		m.conn.Close()
 	}
@@ -166,7 +273,12 @@ func (m *EventMonitor) Close() {
 func main() {
 	// log.Println("Initializing...")
 
-	monitor, err := NewEventMonitor()
+	cfg, err := core.LoadConfig()
+	if err != nil {
+		// log.Printf("⚠️ Using default config: %v", err)
+	}
+
+	monitor, err := NewEventMonitor(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create monitor: %v", err)
 	}
@@ -174,8 +286,17 @@ func main() {
 
 	debounce := core.NewDebounceMiddleware(500 * time.Millisecond)
 	debounce.Exclude(core.EventVolumeChanged)
+	debounce.Exclude(core.EventMicrophoneVolumeChanged)
+	debounce.Exclude(core.EventMicrophoneLevel)
+	debounce.Exclude(core.EventAudioOutputChanged)
+	// Streams of one app share a debounce key; dropping one desyncs the mixer
+	debounce.Exclude(core.EventAudioStreamAdded)
+	debounce.Exclude(core.EventAudioStreamRemoved)
+	debounce.Exclude(core.EventAudioStreamChanged)
 	debounce.Exclude(core.EventBrightnessChanged)
 	debounce.Exclude(core.EventMediaChanged)
+	debounce.Exclude(core.EventMediaLyric)
+	debounce.Exclude(core.EventMediaTrackListChanged)
 
 	monitor.bus.Use(debounce)
 
@@ -183,6 +304,12 @@ func main() {
 	rateLimit.Exclude(core.EventVolumeChanged)
 	rateLimit.Exclude(core.EventVolumeMuted)
 	rateLimit.Exclude(core.EventVolumeUnmuted)
+	rateLimit.Exclude(core.EventMicrophoneVolumeChanged)
+	rateLimit.Exclude(core.EventMicrophoneLevel)
+	rateLimit.Exclude(core.EventMicrophoneMuted)
+	rateLimit.Exclude(core.EventMicrophoneUnmuted)
+	rateLimit.Exclude(core.EventAudioStreamChanged)
+	rateLimit.Exclude(core.EventMediaLyric)
 	monitor.bus.Use(rateLimit)
 
 	monitor.bus.Use(&core.EnrichmentMiddleware{})
@@ -191,26 +318,45 @@ func main() {
 	handler := handlers.NewDBusEmitHandler(monitor.conn)
 	monitor.bus.Subscribe(core.EventMicrophoneStart, handler)
 	monitor.bus.Subscribe(core.EventMicrophoneStop, handler)
+	monitor.bus.Subscribe(core.EventMicrophoneMuted, handler)
+	monitor.bus.Subscribe(core.EventMicrophoneUnmuted, handler)
+	monitor.bus.Subscribe(core.EventMicrophoneVolumeChanged, handler)
+	monitor.bus.Subscribe(core.EventMicrophoneLevel, handler)
 	monitor.bus.Subscribe(core.EventCameraStart, handler)
 	monitor.bus.Subscribe(core.EventCameraStop, handler)
+	monitor.bus.Subscribe(core.EventCameraConnected, handler)
+	monitor.bus.Subscribe(core.EventCameraDisconnected, handler)
+	monitor.bus.Subscribe(core.EventScreenShareStart, handler)
+	monitor.bus.Subscribe(core.EventScreenShareStop, handler)
 	monitor.bus.Subscribe(core.EventBluetoothConnected, handler)
 	monitor.bus.Subscribe(core.EventBluetoothDisconnected, handler)
 	monitor.bus.Subscribe(core.EventNotification, handler)
 	monitor.bus.Subscribe(core.EventVolumeChanged, handler)
 	monitor.bus.Subscribe(core.EventVolumeMuted, handler)
 	monitor.bus.Subscribe(core.EventVolumeUnmuted, handler)
+	monitor.bus.Subscribe(core.EventAudioOutputChanged, handler)
+	monitor.bus.Subscribe(core.EventAudioStreamAdded, handler)
+	monitor.bus.Subscribe(core.EventAudioStreamRemoved, handler)
+	monitor.bus.Subscribe(core.EventAudioStreamChanged, handler)
 	monitor.bus.Subscribe(core.EventBrightnessChanged, handler)
 	monitor.bus.Subscribe(core.EventMediaChanged, handler)
+	monitor.bus.Subscribe(core.EventMediaLyric, handler)
+	monitor.bus.Subscribe(core.EventMediaTrackListChanged, handler)
 	monitor.bus.Subscribe(core.EventBatteryChanged, handler)
 	monitor.bus.Subscribe(core.EventUxplaySharing, handler)
 
-	monitor.RegisterSource(microphone.NewMicrophoneSource())
+	// Before the capture sources so it sees their first sessions
+	monitor.RegisterSource(monitor.privacy)
+	monitor.RegisterSource(microphone.NewMicrophoneSource(monitor.audioBackend, cfg.Microphone))
 	monitor.RegisterSource(camera.NewCameraSource())
+	monitor.RegisterSource(screenshare.NewScreenShareSource())
 	monitor.RegisterSource(bluetooth.NewBluetoothSource(monitor.mediaService))
 	monitor.RegisterSource(notification.NewNotificationSource())
-	monitor.RegisterSource(volume.NewVolumeSource())
+	monitor.RegisterSource(volume.NewVolumeSource(monitor.audioBackend))
 	monitor.RegisterSource(brightness.NewBrightnessSource())
 	monitor.RegisterSource(monitor.batterySource)
+	// Before the media source so it sees the initial playback status
+	monitor.RegisterSource(monitor.spectrum)
 	monitor.RegisterSource(monitor.mediaSource)
 	monitor.RegisterSource(uxplay.NewUxplaySource())
 
@@ -0,0 +1,387 @@
+package audio
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSink   = "@DEFAULT_SINK@"
+	DefaultSource = "@DEFAULT_SOURCE@"
+
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+type subscriber struct {
+	mask SubscriptionMask
+	fn   func(SubscriptionEvent)
+}
+
+// Backend is the audio connection shared by the volume, microphone and media
+// modules. It connects lazily and reconnects when the sound server restarts;
+// subscribers then receive a FacilityServer change event so they can re-read
+// whatever state they track.
+type Backend struct {
+	appName string
+
+	mu           sync.Mutex
+	client       *client
+	subs         map[int]*subscriber
+	nextSub      int
+	reconnecting bool
+	closed       bool
+}
+
+func NewBackend(appName string) *Backend {
+	return &Backend{
+		appName: appName,
+		subs:    make(map[int]*subscriber),
+	}
+}
+
+// Close drops the connection; the backend cannot be used afterwards.
+func (b *Backend) Close() {
+	b.mu.Lock()
+	b.closed = true
+	c := b.client
+	b.client = nil
+	b.mu.Unlock()
+
+	if c != nil {
+		c.Close()
+	}
+}
+
+func (b *Backend) subscriptionMask() SubscriptionMask {
+	var mask SubscriptionMask
+	for _, sub := range b.subs {
+		mask |= sub.mask
+	}
+	return mask
+}
+
+// conn returns the live connection, dialing one if needed.
+func (b *Backend) conn() (*client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, errClosed
+	}
+	if b.client != nil {
+		return b.client, nil
+	}
+
+	c, err := dial(b.appName)
+	if err != nil {
+		return nil, err
+	}
+	b.client = c
+	go b.dispatch(c)
+
+	if mask := b.subscriptionMask(); mask != 0 {
+		c.request(cmdSubscribe, new(tagWriter).u32(uint32(mask)))
+	}
+	return c, nil
+}
+
+func (b *Backend) request(cmd uint32, args *tagWriter) (*tagReader, error) {
+	c, err := b.conn()
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.request(cmd, args)
+	if err == errClosed {
+		// The server went away under us; retry once on a fresh connection.
+		b.dropClient(c)
+		if c, err = b.conn(); err != nil {
+			return nil, err
+		}
+		r, err = c.request(cmd, args)
+	}
+	return r, err
+}
+
+func (b *Backend) dropClient(c *client) {
+	b.mu.Lock()
+	if b.client == c {
+		b.client = nil
+	}
+	b.mu.Unlock()
+	c.Close()
+}
+
+func (b *Backend) dispatch(c *client) {
+	for {
+		select {
+		case ev := <-c.events:
+			b.deliver(ev, false)
+		case <-c.done:
+			b.dropClient(c)
+			b.scheduleReconnect()
+			return
+		}
+	}
+}
+
+// deliver calls every subscriber interested in ev; force skips the mask.
+func (b *Backend) deliver(ev SubscriptionEvent, force bool) {
+	b.mu.Lock()
+	var fns []func(SubscriptionEvent)
+	for _, sub := range b.subs {
+		if force || sub.mask.matches(ev.Facility) {
+			fns = append(fns, sub.fn)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(ev)
+	}
+}
+
+// scheduleReconnect keeps redialing in the background while anyone is
+// subscribed, backing off up to maxReconnectDelay.
+func (b *Backend) scheduleReconnect() {
+	b.mu.Lock()
+	if b.closed || b.reconnecting || len(b.subs) == 0 {
+		b.mu.Unlock()
+		return
+	}
+	b.reconnecting = true
+	b.mu.Unlock()
+
+	go func() {
+		delay := minReconnectDelay
+		for {
+			time.Sleep(delay)
+
+			b.mu.Lock()
+			stop := b.closed || len(b.subs) == 0
+			if stop {
+				b.reconnecting = false
+			}
+			b.mu.Unlock()
+			if stop {
+				return
+			}
+
+			if _, err := b.conn(); err == nil {
+				b.mu.Lock()
+				b.reconnecting = false
+				b.mu.Unlock()
+				b.deliver(SubscriptionEvent{Facility: FacilityServer, Type: EventChange, Index: invalidIndex}, true)
+				return
+			}
+
+			delay *= 2
+			if delay > maxReconnectDelay {
+				delay = maxReconnectDelay
+			}
+		}
+	}()
+}
+
+// Subscribe calls fn for every server event matching mask until the returned
+// function is called. Events are delivered from a single goroutine.
+func (b *Backend) Subscribe(mask SubscriptionMask, fn func(SubscriptionEvent)) func() {
+	b.mu.Lock()
+	id := b.nextSub
+	b.nextSub++
+	b.subs[id] = &subscriber{mask: mask, fn: fn}
+	b.mu.Unlock()
+
+	b.updateSubscription()
+
+	return func() {
+		b.mu.Lock()
+		delete(b.subs, id)
+		b.mu.Unlock()
+		b.updateSubscription()
+	}
+}
+
+func (b *Backend) updateSubscription() {
+	c, err := b.conn()
+	if err != nil {
+		b.scheduleReconnect()
+		return
+	}
+
+	b.mu.Lock()
+	mask := b.subscriptionMask()
+	b.mu.Unlock()
+
+	c.request(cmdSubscribe, new(tagWriter).u32(uint32(mask)))
+}
+
+func (b *Backend) ServerInfo() (ServerInfo, error) {
+	r, err := b.request(cmdGetServerInfo, nil)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	info := parseServerInfo(r)
+	return info, r.err
+}
+
+// Sink looks up a sink by name; DefaultSink selects the default one.
+func (b *Backend) Sink(name string) (Sink, error) {
+	r, err := b.request(cmdGetSinkInfo, new(tagWriter).u32(invalidIndex).str(name))
+	if err != nil {
+		return Sink{}, err
+	}
+	sink := parseSink(r)
+	return sink, r.err
+}
+
+func (b *Backend) Sinks() ([]Sink, error) {
+	r, err := b.request(cmdGetSinkInfoList, nil)
+	if err != nil {
+		return nil, err
+	}
+	var sinks []Sink
+	for !r.done() {
+		sink := parseSink(r)
+		if r.err != nil {
+			return nil, r.err
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// Source looks up a source by name; DefaultSource selects the default one.
+func (b *Backend) Source(name string) (Source, error) {
+	r, err := b.request(cmdGetSourceInfo, new(tagWriter).u32(invalidIndex).str(name))
+	if err != nil {
+		return Source{}, err
+	}
+	source := parseSource(r)
+	return source, r.err
+}
+
+func (b *Backend) Sources() ([]Source, error) {
+	r, err := b.request(cmdGetSourceInfoList, nil)
+	if err != nil {
+		return nil, err
+	}
+	var sources []Source
+	for !r.done() {
+		source := parseSource(r)
+		if r.err != nil {
+			return nil, r.err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+func (b *Backend) SinkInput(index uint32) (SinkInput, error) {
+	r, err := b.request(cmdGetSinkInputInfo, new(tagWriter).u32(index))
+	if err != nil {
+		return SinkInput{}, err
+	}
+	input := parseSinkInput(r)
+	return input, r.err
+}
+
+func (b *Backend) SinkInputs() ([]SinkInput, error) {
+	r, err := b.request(cmdGetSinkInputInfoList, nil)
+	if err != nil {
+		return nil, err
+	}
+	var inputs []SinkInput
+	for !r.done() {
+		input := parseSinkInput(r)
+		if r.err != nil {
+			return nil, r.err
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
+func (b *Backend) SourceOutputs() ([]SourceOutput, error) {
+	r, err := b.request(cmdGetSourceOutputInfoList, nil)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []SourceOutput
+	for !r.done() {
+		output := parseSourceOutput(r)
+		if r.err != nil {
+			return nil, r.err
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+func (b *Backend) Clients() ([]Client, error) {
+	r, err := b.request(cmdGetClientInfoList, nil)
+	if err != nil {
+		return nil, err
+	}
+	var clients []Client
+	for !r.done() {
+		client := parseClient(r)
+		if r.err != nil {
+			return nil, r.err
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+func (b *Backend) SetSinkVolume(name string, vols []uint32) error {
+	_, err := b.request(cmdSetSinkVolume, new(tagWriter).u32(invalidIndex).str(name).cvolume(vols))
+	return err
+}
+
+func (b *Backend) SetSinkMute(name string, muted bool) error {
+	_, err := b.request(cmdSetSinkMute, new(tagWriter).u32(invalidIndex).str(name).boolean(muted))
+	return err
+}
+
+func (b *Backend) SetSourceVolume(name string, vols []uint32) error {
+	_, err := b.request(cmdSetSourceVolume, new(tagWriter).u32(invalidIndex).str(name).cvolume(vols))
+	return err
+}
+
+func (b *Backend) SetSourceMute(name string, muted bool) error {
+	_, err := b.request(cmdSetSourceMute, new(tagWriter).u32(invalidIndex).str(name).boolean(muted))
+	return err
+}
+
+func (b *Backend) SetSinkInputVolume(index uint32, vols []uint32) error {
+	_, err := b.request(cmdSetSinkInputVolume, new(tagWriter).u32(index).cvolume(vols))
+	return err
+}
+
+func (b *Backend) SetSinkInputMute(index uint32, muted bool) error {
+	_, err := b.request(cmdSetSinkInputMute, new(tagWriter).u32(index).boolean(muted))
+	return err
+}
+
+func (b *Backend) SetDefaultSink(name string) error {
+	_, err := b.request(cmdSetDefaultSink, new(tagWriter).str(name))
+	return err
+}
+
+func (b *Backend) SetDefaultSource(name string) error {
+	_, err := b.request(cmdSetDefaultSource, new(tagWriter).str(name))
+	return err
+}
+
+// MoveSinkInput moves a playback stream to the named sink.
+func (b *Backend) MoveSinkInput(index uint32, sinkName string) error {
+	_, err := b.request(cmdMoveSinkInput, new(tagWriter).u32(index).u32(invalidIndex).str(sinkName))
+	return err
+}
+
+// MoveSourceOutput moves a recording stream to the named source.
+func (b *Backend) MoveSourceOutput(index uint32, sourceName string) error {
+	_, err := b.request(cmdMoveSourceOutput, new(tagWriter).u32(index).u32(invalidIndex).str(sourceName))
+	return err
+}
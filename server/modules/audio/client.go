@@ -0,0 +1,349 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Protocol version we speak. The server lays out replies for the lower of
+// its own version and ours.
+const protocolVersion = 32
+
+const (
+	descriptorSize    = 20
+	controlChannel    = 0xFFFFFFFF
+	invalidIndex      = 0xFFFFFFFF
+	maxFrameSize      = 16 * 1024 * 1024
+	cookieSize        = 256
+	requestTimeout    = 5 * time.Second
+	protocolFlagsMask = 0xFFFF0000
+)
+
+// VolumeNorm is the raw volume for 100%.
+const VolumeNorm = 0x10000
+
+// Native protocol commands used by this client.
+const (
+	cmdError                   = 0
+	cmdReply                   = 2
+	cmdCreateRecordStream      = 5
+	cmdDeleteRecordStream      = 6
+	cmdAuth                    = 8
+	cmdSetClientName           = 9
+	cmdGetServerInfo           = 20
+	cmdGetSinkInfo             = 21
+	cmdGetSinkInfoList         = 22
+	cmdGetSourceInfo           = 23
+	cmdGetSourceInfoList       = 24
+	cmdGetClientInfoList       = 28
+	cmdGetSinkInputInfo        = 29
+	cmdGetSinkInputInfoList    = 30
+	cmdGetSourceOutputInfoList = 32
+	cmdSubscribe               = 35
+	cmdSetSinkVolume           = 36
+	cmdSetSinkInputVolume      = 37
+	cmdSetSourceVolume         = 38
+	cmdSetSinkMute             = 39
+	cmdSetSourceMute           = 40
+	cmdSetDefaultSink          = 44
+	cmdSetDefaultSource        = 45
+	cmdRecordStreamKilled      = 65
+	cmdSubscribeEvent          = 66
+	cmdMoveSinkInput           = 67
+	cmdMoveSourceOutput        = 68
+	cmdSetSinkInputMute        = 69
+)
+
+var errClosed = errors.New("pulseaudio connection closed")
+
+// ServerError is an error code returned by the server.
+type ServerError uint32
+
+func (e ServerError) Error() string {
+	names := map[ServerError]string{
+		1: "access denied", 2: "unknown command", 3: "invalid argument",
+		4: "entity exists", 5: "no such entity", 6: "connection refused",
+		7: "protocol error", 8: "timeout", 9: "no authentication key",
+		10: "internal error", 11: "connection terminated", 12: "entity killed",
+		13: "invalid server", 14: "module initialization failed", 15: "bad state",
+		16: "no data", 17: "incompatible protocol version", 18: "too large",
+		19: "not supported",
+	}
+	if name, ok := names[e]; ok {
+		return "pulseaudio: " + name
+	}
+	return fmt.Sprintf("pulseaudio: error %d", uint32(e))
+}
+
+type reply struct {
+	r   *tagReader
+	err error
+}
+
+// client is a single native protocol connection.
+type client struct {
+	conn net.Conn
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	nextTag  uint32
+	pending  map[uint32]chan reply
+	streams  map[uint32]func([]byte)
+	onKilled map[uint32]func()
+	closed   bool
+
+	events chan SubscriptionEvent
+	done   chan struct{}
+}
+
+// socketPath resolves the server socket from $PULSE_SERVER or the user's
+// runtime directory (pipewire-pulse serves the same path).
+func socketPath() string {
+	if server := os.Getenv("PULSE_SERVER"); server != "" {
+		for _, entry := range strings.Fields(server) {
+			if strings.HasPrefix(entry, "unix:") {
+				return strings.TrimPrefix(entry, "unix:")
+			}
+			if strings.HasPrefix(entry, "/") {
+				return entry
+			}
+		}
+	}
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+	return filepath.Join(runtimeDir, "pulse", "native")
+}
+
+// readCookie returns the auth cookie, or zeros when none exists
+// (pipewire-pulse does not check it).
+func readCookie() []byte {
+	var candidates []string
+	if path := os.Getenv("PULSE_COOKIE"); path != "" {
+		candidates = append(candidates, path)
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, "pulse", "cookie"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".pulse-cookie"))
+	}
+	for _, path := range candidates {
+		if data, err := os.ReadFile(path); err == nil && len(data) >= cookieSize {
+			return data[:cookieSize]
+		}
+	}
+	return make([]byte, cookieSize)
+}
+
+func dial(appName string) (*client, error) {
+	conn, err := net.DialTimeout("unix", socketPath(), requestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to pulseaudio: %v", err)
+	}
+
+	c := &client{
+		conn:     conn,
+		pending:  make(map[uint32]chan reply),
+		streams:  make(map[uint32]func([]byte)),
+		onKilled: make(map[uint32]func()),
+		events:   make(chan SubscriptionEvent, 64),
+		done:     make(chan struct{}),
+	}
+	go c.readLoop()
+
+	r, err := c.request(cmdAuth, new(tagWriter).u32(protocolVersion).arbitrary(readCookie()))
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("pulseaudio auth failed: %v", err)
+	}
+	// Replies are parsed with the layouts of protocolVersion, so older
+	// servers (PulseAudio < 12) are not supported.
+	if serverVersion := r.u32() &^ protocolFlagsMask; serverVersion < protocolVersion {
+		c.Close()
+		return nil, fmt.Errorf("pulseaudio protocol %d too old", serverVersion)
+	}
+
+	props := map[string]string{
+		"application.name":           appName,
+		"application.process.id":     fmt.Sprint(os.Getpid()),
+		"application.process.binary": filepath.Base(os.Args[0]),
+	}
+	if _, err := c.request(cmdSetClientName, new(tagWriter).propList(props)); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to set client name: %v", err)
+	}
+
+	return c, nil
+}
+
+func (c *client) Close() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	pending := c.pending
+	c.pending = make(map[uint32]chan reply)
+	killed := c.onKilled
+	c.onKilled = make(map[uint32]func())
+	c.streams = make(map[uint32]func([]byte))
+	c.mu.Unlock()
+
+	c.conn.Close()
+	for _, ch := range pending {
+		ch <- reply{err: errClosed}
+	}
+	for _, fn := range killed {
+		fn()
+	}
+	close(c.done)
+}
+
+// request sends a command and waits for its reply.
+func (c *client) request(cmd uint32, args *tagWriter) (*tagReader, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, errClosed
+	}
+	tag := c.nextTag
+	c.nextTag++
+	ch := make(chan reply, 1)
+	c.pending[tag] = ch
+	c.mu.Unlock()
+
+	payload := new(tagWriter).u32(cmd).u32(tag)
+	if args != nil {
+		payload.buf = append(payload.buf, args.buf...)
+	}
+
+	if err := c.writeFrame(controlChannel, payload.buf); err != nil {
+		c.mu.Lock()
+		delete(c.pending, tag)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	timer := time.NewTimer(requestTimeout)
+	defer timer.Stop()
+
+	select {
+	case rep := <-ch:
+		return rep.r, rep.err
+	case <-timer.C:
+		c.mu.Lock()
+		delete(c.pending, tag)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("pulseaudio request %d timed out", cmd)
+	}
+}
+
+func (c *client) writeFrame(channel uint32, payload []byte) error {
+	frame := make([]byte, descriptorSize, descriptorSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:], channel)
+	frame = append(frame, payload...)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(requestTimeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func (c *client) readLoop() {
+	defer c.Close()
+
+	desc := make([]byte, descriptorSize)
+	for {
+		if _, err := io.ReadFull(c.conn, desc); err != nil {
+			return
+		}
+		length := binary.BigEndian.Uint32(desc[0:])
+		channel := binary.BigEndian.Uint32(desc[4:])
+		if length > maxFrameSize {
+			return
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.conn, payload); err != nil {
+			return
+		}
+
+		if channel != controlChannel {
+			c.mu.Lock()
+			fn := c.streams[channel]
+			c.mu.Unlock()
+			if fn != nil {
+				fn(payload)
+			}
+			continue
+		}
+
+		c.handleControl(payload)
+	}
+}
+
+func (c *client) handleControl(payload []byte) {
+	r := &tagReader{buf: payload}
+	cmd := r.u32()
+	tag := r.u32()
+	if r.err != nil {
+		return
+	}
+
+	switch cmd {
+	case cmdReply, cmdError:
+		c.mu.Lock()
+		ch, ok := c.pending[tag]
+		delete(c.pending, tag)
+		c.mu.Unlock()
+		if !ok {
+			return
+		}
+		if cmd == cmdError {
+			ch <- reply{err: ServerError(r.u32())}
+		} else {
+			ch <- reply{r: r}
+		}
+
+	case cmdSubscribeEvent:
+		t := r.u32()
+		index := r.u32()
+		if r.err != nil {
+			return
+		}
+		ev := SubscriptionEvent{
+			Facility: Facility(t & facilityMask),
+			Type:     EventKind(t & eventTypeMask),
+			Index:    index,
+		}
+		select {
+		case c.events <- ev:
+		default:
+			// Listeners are behind; they re-read state on the next event anyway.
+		}
+
+	case cmdRecordStreamKilled:
+		channel := r.u32()
+		c.mu.Lock()
+		fn := c.onKilled[channel]
+		delete(c.onKilled, channel)
+		delete(c.streams, channel)
+		c.mu.Unlock()
+		if fn != nil {
+			fn()
+		}
+	}
+}
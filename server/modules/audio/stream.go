@@ -0,0 +1,179 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+)
+
+const (
+	sampleFloat32LE = 5
+
+	// PulseAudio's default for buffer attributes the client leaves to the server.
+	bufferDefault = 0xFFFFFFFF
+
+	channelMono = 0
+
+	// GNOME Shell leaves streams of its sound settings panel out of the
+	// "microphone in use" indicator, matching on this application id.
+	shellHiddenAppID = "org.gnome.VolumeControl"
+)
+
+// RecordOptions describes a mono float capture stream.
+type RecordOptions struct {
+	// Source is the source name to record; sink monitors work too.
+	Source string
+	// Rate is the sample rate. With PeakDetect every sample is the peak of
+	// the input over 1/Rate seconds, so a low rate is cheap.
+	Rate       uint32
+	PeakDetect bool
+	// FragmentSize is how many bytes the server batches per packet; zero
+	// lets the server choose.
+	FragmentSize uint32
+	// Hidden keeps the stream out of GNOME Shell's microphone indicator, for
+	// analysis streams that would otherwise show it whenever they run. Mixers
+	// then list the stream under GNOME Settings.
+	Hidden bool
+	Props  map[string]string
+}
+
+// RecordStream is an open capture stream.
+type RecordStream struct {
+	client  *client
+	channel uint32
+	Index   uint32
+
+	closeOnce sync.Once
+}
+
+// Record opens a capture stream. onData receives the samples of each packet
+// from the connection's reader goroutine and must not block; onEnd runs once
+// if the server kills the stream or the connection drops.
+func (b *Backend) Record(opts RecordOptions, onData func([]float32), onEnd func()) (*RecordStream, error) {
+	c, err := b.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	props := map[string]string{
+		"media.name":             "Level meter",
+		"application.process.id": fmt.Sprint(os.Getpid()),
+	}
+	if opts.Hidden {
+		props["application.id"] = shellHiddenAppID
+	}
+	for k, v := range opts.Props {
+		props[k] = v
+	}
+
+	fragsize := opts.FragmentSize
+	if fragsize == 0 {
+		fragsize = bufferDefault
+	}
+
+	// Field order of CREATE_RECORD_STREAM at protocol version 32.
+	args := new(tagWriter)
+	args.sampleSpec(SampleSpec{Format: sampleFloat32LE, Channels: 1, Rate: opts.Rate})
+	args.channelMap([]uint8{channelMono})
+	args.u32(invalidIndex)
+	args.str(opts.Source)
+	args.u32(bufferDefault) // maxlength
+	args.boolean(false)     // start corked
+	args.u32(fragsize)
+	args.boolean(false) // no_remap
+	args.boolean(false) // no_remix
+	args.boolean(false) // fix_format
+	args.boolean(false) // fix_rate
+	args.boolean(false) // fix_channels
+	args.boolean(true)  // dont_move
+	args.boolean(false) // variable_rate
+	args.boolean(opts.PeakDetect)
+	args.boolean(true) // adjust_latency
+	args.propList(props)
+	args.u32(invalidIndex) // direct_on_input
+	args.boolean(false)    // early_requests
+	args.boolean(false)    // dont_inhibit_auto_suspend
+	args.boolean(false)    // fail_on_suspend
+	args.u8(0)             // format count
+	args.cvolume([]uint32{VolumeNorm})
+	args.boolean(false) // start muted
+	args.boolean(false) // volume set
+	args.boolean(false) // muted set
+	args.boolean(false) // relative volume
+	args.boolean(false) // passthrough
+
+	r, err := c.request(cmdCreateRecordStream, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create record stream: %v", err)
+	}
+	stream := &RecordStream{client: c, channel: r.u32(), Index: r.u32()}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var ended sync.Once
+	end := func() {
+		ended.Do(func() {
+			if onEnd != nil {
+				onEnd()
+			}
+		})
+	}
+
+	var leftover []byte
+	c.mu.Lock()
+	c.streams[stream.channel] = func(data []byte) {
+		if len(leftover) > 0 {
+			data = append(leftover, data...)
+			leftover = nil
+		}
+		n := len(data) / 4
+		if rest := data[n*4:]; len(rest) > 0 {
+			leftover = append([]byte(nil), rest...)
+		}
+		samples := make([]float32, n)
+		for i := range samples {
+			samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+		}
+		onData(samples)
+	}
+	c.onKilled[stream.channel] = end
+	closed := c.closed
+	c.mu.Unlock()
+
+	if closed {
+		end()
+	}
+	return stream, nil
+}
+
+// Close deletes the stream on the server. onEnd is not called.
+func (s *RecordStream) Close() {
+	s.closeOnce.Do(func() {
+		c := s.client
+		c.mu.Lock()
+		delete(c.streams, s.channel)
+		delete(c.onKilled, s.channel)
+		c.mu.Unlock()
+
+		c.request(cmdDeleteRecordStream, new(tagWriter).u32(s.channel))
+	})
+}
+
+// PeakAndRMS returns the absolute peak and the root mean square of samples.
+func PeakAndRMS(samples []float32) (peak, rms float64) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range samples {
+		a := math.Abs(float64(v))
+		if a > peak {
+			peak = a
+		}
+		sum += a * a
+	}
+	return peak, math.Sqrt(sum / float64(len(samples)))
+}
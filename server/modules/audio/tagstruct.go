@@ -0,0 +1,340 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Tag bytes of the PulseAudio native protocol tagstruct encoding.
+const (
+	tagString     = 't'
+	tagStringNull = 'N'
+	tagU32        = 'L'
+	tagU8         = 'B'
+	tagSampleSpec = 'a'
+	tagArbitrary  = 'x'
+	tagBoolTrue   = '1'
+	tagBoolFalse  = '0'
+	tagUsec       = 'U'
+	tagChannelMap = 'm'
+	tagCvolume    = 'v'
+	tagPropList   = 'P'
+	tagVolume     = 'V'
+	tagFormatInfo = 'f'
+)
+
+type SampleSpec struct {
+	Format   uint8
+	Channels uint8
+	Rate     uint32
+}
+
+// tagWriter builds a tagstruct payload.
+type tagWriter struct {
+	buf []byte
+}
+
+func (w *tagWriter) u32(v uint32) *tagWriter {
+	w.buf = append(w.buf, tagU32)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+	return w
+}
+
+func (w *tagWriter) u8(v uint8) *tagWriter {
+	w.buf = append(w.buf, tagU8, v)
+	return w
+}
+
+func (w *tagWriter) boolean(v bool) *tagWriter {
+	if v {
+		w.buf = append(w.buf, tagBoolTrue)
+	} else {
+		w.buf = append(w.buf, tagBoolFalse)
+	}
+	return w
+}
+
+// str writes a string; an empty string is sent as the null string.
+func (w *tagWriter) str(s string) *tagWriter {
+	if s == "" {
+		w.buf = append(w.buf, tagStringNull)
+		return w
+	}
+	w.buf = append(w.buf, tagString)
+	w.buf = append(w.buf, s...)
+	w.buf = append(w.buf, 0)
+	return w
+}
+
+func (w *tagWriter) arbitrary(data []byte) *tagWriter {
+	w.buf = append(w.buf, tagArbitrary)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(len(data)))
+	w.buf = append(w.buf, data...)
+	return w
+}
+
+func (w *tagWriter) sampleSpec(ss SampleSpec) *tagWriter {
+	w.buf = append(w.buf, tagSampleSpec, ss.Format, ss.Channels)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, ss.Rate)
+	return w
+}
+
+func (w *tagWriter) channelMap(positions []uint8) *tagWriter {
+	w.buf = append(w.buf, tagChannelMap, uint8(len(positions)))
+	w.buf = append(w.buf, positions...)
+	return w
+}
+
+func (w *tagWriter) cvolume(vols []uint32) *tagWriter {
+	w.buf = append(w.buf, tagCvolume, uint8(len(vols)))
+	for _, v := range vols {
+		w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+	}
+	return w
+}
+
+func (w *tagWriter) propList(props map[string]string) *tagWriter {
+	w.buf = append(w.buf, tagPropList)
+	for k, v := range props {
+		value := append([]byte(v), 0)
+		w.str(k)
+		w.u32(uint32(len(value)))
+		w.arbitrary(value)
+	}
+	w.buf = append(w.buf, tagStringNull)
+	return w
+}
+
+// tagReader decodes a tagstruct payload. The first error sticks and makes
+// every later read return zero values.
+type tagReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *tagReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("tagstruct: "+format, args...)
+	}
+}
+
+func (r *tagReader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.pos+n > len(r.buf) {
+		r.fail("short read at %d", r.pos)
+		return nil
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *tagReader) expect(tag byte) bool {
+	b := r.take(1)
+	if b == nil {
+		return false
+	}
+	if b[0] != tag {
+		r.fail("expected tag %q, got %q at %d", tag, b[0], r.pos-1)
+		return false
+	}
+	return true
+}
+
+func (r *tagReader) done() bool {
+	return r.err != nil || r.pos >= len(r.buf)
+}
+
+func (r *tagReader) u32() uint32 {
+	if !r.expect(tagU32) {
+		return 0
+	}
+	if b := r.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *tagReader) u8() uint8 {
+	if !r.expect(tagU8) {
+		return 0
+	}
+	if b := r.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *tagReader) usec() uint64 {
+	if !r.expect(tagUsec) {
+		return 0
+	}
+	if b := r.take(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *tagReader) volume() uint32 {
+	if !r.expect(tagVolume) {
+		return 0
+	}
+	if b := r.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *tagReader) boolean() bool {
+	b := r.take(1)
+	if b == nil {
+		return false
+	}
+	switch b[0] {
+	case tagBoolTrue:
+		return true
+	case tagBoolFalse:
+		return false
+	}
+	r.fail("expected boolean, got %q", b[0])
+	return false
+}
+
+func (r *tagReader) str() string {
+	b := r.take(1)
+	if b == nil {
+		return ""
+	}
+	switch b[0] {
+	case tagStringNull:
+		return ""
+	case tagString:
+	default:
+		r.fail("expected string, got %q", b[0])
+		return ""
+	}
+
+	start := r.pos
+	for r.pos < len(r.buf) && r.buf[r.pos] != 0 {
+		r.pos++
+	}
+	if r.pos >= len(r.buf) {
+		r.fail("unterminated string")
+		return ""
+	}
+	s := string(r.buf[start:r.pos])
+	r.pos++
+	return s
+}
+
+func (r *tagReader) arbitrary() []byte {
+	if !r.expect(tagArbitrary) {
+		return nil
+	}
+	lb := r.take(4)
+	if lb == nil {
+		return nil
+	}
+	return r.take(int(binary.BigEndian.Uint32(lb)))
+}
+
+func (r *tagReader) sampleSpec() SampleSpec {
+	if !r.expect(tagSampleSpec) {
+		return SampleSpec{}
+	}
+	b := r.take(6)
+	if b == nil {
+		return SampleSpec{}
+	}
+	return SampleSpec{Format: b[0], Channels: b[1], Rate: binary.BigEndian.Uint32(b[2:])}
+}
+
+func (r *tagReader) channelMap() []uint8 {
+	if !r.expect(tagChannelMap) {
+		return nil
+	}
+	n := r.take(1)
+	if n == nil {
+		return nil
+	}
+	positions := r.take(int(n[0]))
+	return append([]uint8(nil), positions...)
+}
+
+func (r *tagReader) cvolume() []uint32 {
+	if !r.expect(tagCvolume) {
+		return nil
+	}
+	n := r.take(1)
+	if n == nil {
+		return nil
+	}
+	vols := make([]uint32, 0, n[0])
+	for i := 0; i < int(n[0]); i++ {
+		b := r.take(4)
+		if b == nil {
+			return nil
+		}
+		vols = append(vols, binary.BigEndian.Uint32(b))
+	}
+	return vols
+}
+
+func (r *tagReader) propList() map[string]string {
+	if !r.expect(tagPropList) {
+		return nil
+	}
+	props := make(map[string]string)
+	for r.err == nil {
+		if r.pos < len(r.buf) && r.buf[r.pos] == tagStringNull {
+			r.pos++
+			break
+		}
+		key := r.str()
+		length := r.u32()
+		value := r.arbitrary()
+		if r.err != nil {
+			break
+		}
+		if int(length) != len(value) {
+			r.fail("proplist length mismatch for %s", key)
+			break
+		}
+		// Values are usually NUL terminated strings.
+		if n := len(value); n > 0 && value[n-1] == 0 {
+			value = value[:n-1]
+		}
+		props[key] = string(value)
+	}
+	return props
+}
+
+// formatInfo skips a format info entry (encoding plus proplist).
+func (r *tagReader) formatInfo() {
+	if !r.expect(tagFormatInfo) {
+		return
+	}
+	r.u8()
+	r.propList()
+}
+
+// volumePercent converts a raw volume to the percentage pactl reports.
+func volumePercent(v uint32) int {
+	return int(math.Round(float64(v) * 100 / float64(VolumeNorm)))
+}
+
+// maxVolume returns the loudest channel volume, which is what GNOME shows
+// as the single level of a device (pa_cvolume_max).
+func maxVolume(vols []uint32) uint32 {
+	var max uint32
+	for _, v := range vols {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
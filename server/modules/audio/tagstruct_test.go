@@ -0,0 +1,106 @@
+package audio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagstructRoundTrip(t *testing.T) {
+	props := map[string]string{
+		"application.name":           "Dynamic Island",
+		"application.process.binary": "dynamic-island-server",
+		"media.role":                 "",
+	}
+	vols := []uint32{VolumeNorm, VolumeNorm / 2, 0}
+
+	w := new(tagWriter).
+		u32(0).
+		u32(invalidIndex).
+		str("alsa_output.pci-0000_00_1f.3.analog-stereo").
+		str("").
+		propList(props).
+		cvolume(vols).
+		u32(7)
+
+	r := &tagReader{buf: w.buf}
+	if got := r.u32(); got != 0 {
+		t.Errorf("u32 = %d, want 0", got)
+	}
+	if got := r.u32(); got != invalidIndex {
+		t.Errorf("u32 = %#x, want %#x", got, uint32(invalidIndex))
+	}
+	if got := r.str(); got != "alsa_output.pci-0000_00_1f.3.analog-stereo" {
+		t.Errorf("str = %q", got)
+	}
+	if got := r.str(); got != "" {
+		t.Errorf("null str = %q, want empty", got)
+	}
+	if got := r.propList(); !reflect.DeepEqual(got, props) {
+		t.Errorf("propList = %v, want %v", got, props)
+	}
+	if got := r.cvolume(); !reflect.DeepEqual(got, vols) {
+		t.Errorf("cvolume = %v, want %v", got, vols)
+	}
+	if got := r.u32(); got != 7 {
+		t.Errorf("u32 after cvolume = %d, want 7", got)
+	}
+	if r.err != nil {
+		t.Fatalf("unexpected error: %v", r.err)
+	}
+	if !r.done() {
+		t.Errorf("%d bytes left over", len(r.buf)-r.pos)
+	}
+}
+
+func TestTagstructEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		w    *tagWriter
+		want []byte
+	}{
+		{"u32", new(tagWriter).u32(0x01020304), []byte{'L', 1, 2, 3, 4}},
+		{"str", new(tagWriter).str("ab"), []byte{'t', 'a', 'b', 0}},
+		{"null str", new(tagWriter).str(""), []byte{'N'}},
+		{"cvolume", new(tagWriter).cvolume([]uint32{VolumeNorm, 1}), []byte{'v', 2, 0, 1, 0, 0, 0, 0, 0, 1}},
+		{
+			"propList",
+			new(tagWriter).propList(map[string]string{"k": "v"}),
+			[]byte{'P', 't', 'k', 0, 'L', 0, 0, 0, 2, 'x', 0, 0, 0, 2, 'v', 0, 'N'},
+		},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.w.buf, tt.want) {
+			t.Errorf("%s: encoded %q, want %q", tt.name, tt.w.buf, tt.want)
+		}
+	}
+}
+
+func TestTagstructErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  []byte
+		read func(r *tagReader)
+	}{
+		{"wrong tag", []byte{'t', 'a', 0}, func(r *tagReader) { r.u32() }},
+		{"short u32", []byte{'L', 0, 0}, func(r *tagReader) { r.u32() }},
+		{"unterminated str", []byte{'t', 'a', 'b'}, func(r *tagReader) { r.str() }},
+		{"short cvolume", []byte{'v', 2, 0, 1, 0, 0}, func(r *tagReader) { r.cvolume() }},
+		{"propList without end", []byte{'P', 't', 'k', 0}, func(r *tagReader) { r.propList() }},
+		{
+			"propList length mismatch",
+			[]byte{'P', 't', 'k', 0, 'L', 0, 0, 0, 3, 'x', 0, 0, 0, 2, 'v', 0, 'N'},
+			func(r *tagReader) { r.propList() },
+		},
+	}
+	for _, tt := range tests {
+		r := &tagReader{buf: tt.buf}
+		tt.read(r)
+		if r.err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+		// The first error sticks
+		if got := r.u32(); got != 0 || r.err == nil {
+			t.Errorf("%s: read after error returned %d", tt.name, got)
+		}
+	}
+}
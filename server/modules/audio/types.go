@@ -0,0 +1,368 @@
+package audio
+
+import (
+	"math"
+	"strconv"
+)
+
+// Facility is the kind of object a subscription event refers to.
+type Facility uint32
+
+const (
+	FacilitySink         Facility = 0
+	FacilitySource       Facility = 1
+	FacilitySinkInput    Facility = 2
+	FacilitySourceOutput Facility = 3
+	FacilityClient       Facility = 5
+	FacilityServer       Facility = 7
+	FacilityCard         Facility = 9
+)
+
+// EventKind says whether the object was created, changed or removed.
+type EventKind uint32
+
+const (
+	EventNew    EventKind = 0x00
+	EventChange EventKind = 0x10
+	EventRemove EventKind = 0x20
+)
+
+const (
+	facilityMask  = 0x0F
+	eventTypeMask = 0x30
+)
+
+// SubscriptionMask selects the facilities a subscriber is interested in.
+type SubscriptionMask uint32
+
+const (
+	MaskSink         SubscriptionMask = 0x0001
+	MaskSource       SubscriptionMask = 0x0002
+	MaskSinkInput    SubscriptionMask = 0x0004
+	MaskSourceOutput SubscriptionMask = 0x0008
+	MaskClient       SubscriptionMask = 0x0020
+	MaskServer       SubscriptionMask = 0x0080
+	MaskCard         SubscriptionMask = 0x0200
+	MaskAll          SubscriptionMask = 0x02FF
+)
+
+type SubscriptionEvent struct {
+	Facility Facility
+	Type     EventKind
+	Index    uint32
+}
+
+func (m SubscriptionMask) matches(f Facility) bool {
+	return m&(1<<f) != 0
+}
+
+type ServerInfo struct {
+	PackageName    string
+	PackageVersion string
+	DefaultSink    string
+	DefaultSource  string
+	SampleSpec     SampleSpec
+}
+
+// Port availability as reported by jack detection.
+const (
+	PortAvailableUnknown = 0
+	PortAvailableNo      = 1
+	PortAvailableYes     = 2
+)
+
+type Port struct {
+	Name        string
+	Description string
+	Priority    uint32
+	Available   uint32
+}
+
+type Sink struct {
+	Index             uint32
+	Name              string
+	Description       string
+	SampleSpec        SampleSpec
+	ChannelMap        []uint8
+	Volume            []uint32
+	Muted             bool
+	MonitorSource     uint32
+	MonitorSourceName string
+	Driver            string
+	Flags             uint32
+	Props             map[string]string
+	BaseVolume        uint32
+	State             uint32
+	Card              uint32
+	Ports             []Port
+	ActivePort        string
+}
+
+type Source struct {
+	Index             uint32
+	Name              string
+	Description       string
+	SampleSpec        SampleSpec
+	ChannelMap        []uint8
+	Volume            []uint32
+	Muted             bool
+	MonitorOfSink     uint32
+	MonitorOfSinkName string
+	Driver            string
+	Flags             uint32
+	Props             map[string]string
+	BaseVolume        uint32
+	State             uint32
+	Card              uint32
+	Ports             []Port
+	ActivePort        string
+}
+
+type SinkInput struct {
+	Index          uint32
+	Name           string
+	Client         uint32
+	Sink           uint32
+	SampleSpec     SampleSpec
+	ChannelMap     []uint8
+	Volume         []uint32
+	Muted          bool
+	Corked         bool
+	HasVolume      bool
+	VolumeWritable bool
+	Driver         string
+	Props          map[string]string
+}
+
+type SourceOutput struct {
+	Index          uint32
+	Name           string
+	Client         uint32
+	Source         uint32
+	SampleSpec     SampleSpec
+	ChannelMap     []uint8
+	Volume         []uint32
+	Muted          bool
+	Corked         bool
+	HasVolume      bool
+	VolumeWritable bool
+	Driver         string
+	Props          map[string]string
+}
+
+type Client struct {
+	Index  uint32
+	Name   string
+	Driver string
+	Props  map[string]string
+}
+
+// Level returns the sink volume in percent.
+func (s *Sink) Level() int { return volumePercent(maxVolume(s.Volume)) }
+
+// Available reports whether the sink can be heard, i.e. its active port is
+// not known to be unplugged.
+func (s *Sink) Available() bool { return portAvailable(s.Ports, s.ActivePort) }
+
+// Level returns the source volume in percent.
+func (s *Source) Level() int { return volumePercent(maxVolume(s.Volume)) }
+
+// Available reports whether the source's active port is not known to be
+// unplugged.
+func (s *Source) Available() bool { return portAvailable(s.Ports, s.ActivePort) }
+
+// IsMonitor reports whether the source is the monitor of a sink.
+func (s *Source) IsMonitor() bool { return s.MonitorOfSink != invalidIndex }
+
+// Level returns the stream volume in percent.
+func (s *SinkInput) Level() int { return volumePercent(maxVolume(s.Volume)) }
+
+// PID returns application.process.id, or 0 when the client did not set it.
+func (s *SinkInput) PID() int { return propPID(s.Props) }
+
+// Level returns the stream volume in percent.
+func (s *SourceOutput) Level() int { return volumePercent(maxVolume(s.Volume)) }
+
+// PID returns application.process.id, or 0 when the client did not set it.
+func (s *SourceOutput) PID() int { return propPID(s.Props) }
+
+func portAvailable(ports []Port, active string) bool {
+	for _, p := range ports {
+		if p.Name == active {
+			return p.Available != PortAvailableNo
+		}
+	}
+	return true
+}
+
+func propPID(props map[string]string) int {
+	pid, _ := strconv.Atoi(props["application.process.id"])
+	return pid
+}
+
+// UniformVolume builds a channel volume setting every channel to percent,
+// which is what `pactl set-*-volume N%` does.
+func UniformVolume(channels int, percent int) []uint32 {
+	if channels <= 0 {
+		channels = 1
+	}
+	if percent < 0 {
+		percent = 0
+	}
+	raw := uint32(math.Round(float64(percent) * VolumeNorm / 100))
+	vols := make([]uint32, channels)
+	for i := range vols {
+		vols[i] = raw
+	}
+	return vols
+}
+
+func parseServerInfo(r *tagReader) ServerInfo {
+	info := ServerInfo{
+		PackageName:    r.str(),
+		PackageVersion: r.str(),
+	}
+	r.str() // user name
+	r.str() // host name
+	info.SampleSpec = r.sampleSpec()
+	info.DefaultSink = r.str()
+	info.DefaultSource = r.str()
+	r.u32() // cookie
+	r.channelMap()
+	return info
+}
+
+func parsePorts(r *tagReader) ([]Port, string) {
+	n := r.u32()
+	var ports []Port
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		ports = append(ports, Port{
+			Name:        r.str(),
+			Description: r.str(),
+			Priority:    r.u32(),
+			Available:   r.u32(),
+		})
+	}
+	active := r.str()
+	return ports, active
+}
+
+func skipFormats(r *tagReader) {
+	n := r.u8()
+	for i := uint8(0); i < n && r.err == nil; i++ {
+		r.formatInfo()
+	}
+}
+
+func parseSink(r *tagReader) Sink {
+	s := Sink{
+		Index:       r.u32(),
+		Name:        r.str(),
+		Description: r.str(),
+		SampleSpec:  r.sampleSpec(),
+		ChannelMap:  r.channelMap(),
+	}
+	r.u32() // owner module
+	s.Volume = r.cvolume()
+	s.Muted = r.boolean()
+	s.MonitorSource = r.u32()
+	s.MonitorSourceName = r.str()
+	r.usec() // latency
+	s.Driver = r.str()
+	s.Flags = r.u32()
+	s.Props = r.propList()
+	r.usec() // configured latency
+	s.BaseVolume = r.volume()
+	s.State = r.u32()
+	r.u32() // volume steps
+	s.Card = r.u32()
+	s.Ports, s.ActivePort = parsePorts(r)
+	skipFormats(r)
+	return s
+}
+
+func parseSource(r *tagReader) Source {
+	s := Source{
+		Index:       r.u32(),
+		Name:        r.str(),
+		Description: r.str(),
+		SampleSpec:  r.sampleSpec(),
+		ChannelMap:  r.channelMap(),
+	}
+	r.u32() // owner module
+	s.Volume = r.cvolume()
+	s.Muted = r.boolean()
+	s.MonitorOfSink = r.u32()
+	s.MonitorOfSinkName = r.str()
+	r.usec() // latency
+	s.Driver = r.str()
+	s.Flags = r.u32()
+	s.Props = r.propList()
+	r.usec() // configured latency
+	s.BaseVolume = r.volume()
+	s.State = r.u32()
+	r.u32() // volume steps
+	s.Card = r.u32()
+	s.Ports, s.ActivePort = parsePorts(r)
+	skipFormats(r)
+	return s
+}
+
+func parseSinkInput(r *tagReader) SinkInput {
+	s := SinkInput{
+		Index: r.u32(),
+		Name:  r.str(),
+	}
+	r.u32() // owner module
+	s.Client = r.u32()
+	s.Sink = r.u32()
+	s.SampleSpec = r.sampleSpec()
+	s.ChannelMap = r.channelMap()
+	s.Volume = r.cvolume()
+	r.usec() // buffer latency
+	r.usec() // sink latency
+	r.str()  // resample method
+	s.Driver = r.str()
+	s.Muted = r.boolean()
+	s.Props = r.propList()
+	s.Corked = r.boolean()
+	s.HasVolume = r.boolean()
+	s.VolumeWritable = r.boolean()
+	r.formatInfo()
+	return s
+}
+
+func parseSourceOutput(r *tagReader) SourceOutput {
+	s := SourceOutput{
+		Index: r.u32(),
+		Name:  r.str(),
+	}
+	r.u32() // owner module
+	s.Client = r.u32()
+	s.Source = r.u32()
+	s.SampleSpec = r.sampleSpec()
+	s.ChannelMap = r.channelMap()
+	r.usec() // buffer latency
+	r.usec() // source latency
+	r.str()  // resample method
+	s.Driver = r.str()
+	s.Props = r.propList()
+	s.Corked = r.boolean()
+	s.Volume = r.cvolume()
+	s.Muted = r.boolean()
+	s.HasVolume = r.boolean()
+	s.VolumeWritable = r.boolean()
+	r.formatInfo()
+	return s
+}
+
+func parseClient(r *tagReader) Client {
+	c := Client{
+		Index: r.u32(),
+		Name:  r.str(),
+	}
+	r.u32() // owner module
+	c.Driver = r.str()
+	c.Props = r.propList()
+	return c
+}
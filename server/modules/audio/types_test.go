@@ -0,0 +1,95 @@
+package audio
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sinkInfoReply is the body of a GET_SINK_INFO reply for a stereo PCI sink,
+// laid out byte by byte as PulseAudio's sink_fill_tagstruct writes it at
+// protocol version 32, independently of tagWriter.
+var sinkInfoReply = concat(
+	[]byte{'L', 0, 0, 0, 1},                                   // index
+	[]byte("talsa_output.pci-0000_00_1f.3.analog-stereo\x00"), // name
+	[]byte("tBuilt-in Audio Analog Stereo\x00"),               // description
+	[]byte{'a', 3, 2, 0, 0, 0xBB, 0x80},                       // s16le, 2 channels, 48000 Hz
+	[]byte{'m', 2, 1, 2},                                      // front-left, front-right
+	[]byte{'L', 0, 0, 0, 7},                                   // owner module
+	[]byte{'v', 2, 0, 1, 0, 0, 0, 0, 0x80, 0},                 // 100%, 50%
+	[]byte{'0'},             // not muted
+	[]byte{'L', 0, 0, 0, 1}, // monitor source
+	[]byte("talsa_output.pci-0000_00_1f.3.analog-stereo.monitor\x00"),
+	[]byte{'U', 0, 0, 0, 0, 0, 0, 0x2E, 0xE0}, // latency
+	[]byte("tmodule-alsa-card.c\x00"),         // driver
+	[]byte{'L', 0, 0, 0, 0x35},                // flags
+	[]byte{'P'},
+	[]byte("tdevice.bus\x00"), []byte{'L', 0, 0, 0, 4}, []byte{'x', 0, 0, 0, 4}, []byte("pci\x00"),
+	[]byte("tdevice.icon_name\x00"), []byte{'L', 0, 0, 0, 11}, []byte{'x', 0, 0, 0, 11}, []byte("audio-card\x00"),
+	[]byte{'N'},
+	[]byte{'U', 0, 0, 0, 0, 0, 0, 0, 0}, // configured latency
+	[]byte{'V', 0, 1, 0, 0},             // base volume
+	[]byte{'L', 0, 0, 0, 0},             // state: running
+	[]byte{'L', 0, 1, 0, 1},             // volume steps
+	[]byte{'L', 0, 0, 0, 0},             // card
+	[]byte{'L', 0, 0, 0, 2},             // ports
+	[]byte("tanalog-output-speaker\x00tSpeakers\x00"), []byte{'L', 0, 0, 0x27, 0x10}, []byte{'L', 0, 0, 0, 0},
+	[]byte("tanalog-output-headphones\x00tHeadphones\x00"), []byte{'L', 0, 0, 0x26, 0xAC}, []byte{'L', 0, 0, 0, 1},
+	[]byte("tanalog-output-speaker\x00"), // active port
+	[]byte{'B', 1},                       // formats
+	[]byte{'f', 'B', 1, 'P', 'N'},        // PCM, no properties
+)
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func TestParseSink(t *testing.T) {
+	r := &tagReader{buf: sinkInfoReply}
+	sink := parseSink(r)
+	if r.err != nil {
+		t.Fatalf("parse failed: %v", r.err)
+	}
+	if !r.done() {
+		t.Errorf("%d bytes left over", len(r.buf)-r.pos)
+	}
+
+	want := Sink{
+		Index:             1,
+		Name:              "alsa_output.pci-0000_00_1f.3.analog-stereo",
+		Description:       "Built-in Audio Analog Stereo",
+		SampleSpec:        SampleSpec{Format: 3, Channels: 2, Rate: 48000},
+		ChannelMap:        []uint8{1, 2},
+		Volume:            []uint32{VolumeNorm, VolumeNorm / 2},
+		Muted:             false,
+		MonitorSource:     1,
+		MonitorSourceName: "alsa_output.pci-0000_00_1f.3.analog-stereo.monitor",
+		Driver:            "module-alsa-card.c",
+		Flags:             0x35,
+		Props:             map[string]string{"device.bus": "pci", "device.icon_name": "audio-card"},
+		BaseVolume:        VolumeNorm,
+		State:             0,
+		Card:              0,
+		Ports: []Port{
+			{Name: "analog-output-speaker", Description: "Speakers", Priority: 10000, Available: PortAvailableUnknown},
+			{Name: "analog-output-headphones", Description: "Headphones", Priority: 9900, Available: PortAvailableNo},
+		},
+		ActivePort: "analog-output-speaker",
+	}
+	if !reflect.DeepEqual(sink, want) {
+		t.Errorf("parsed sink\n%+v\nwant\n%+v", sink, want)
+	}
+}
+
+func TestParseSinkTruncated(t *testing.T) {
+	for n := 0; n < len(sinkInfoReply); n++ {
+		r := &tagReader{buf: sinkInfoReply[:n]}
+		parseSink(r)
+		if r.err == nil {
+			t.Errorf("no error parsing %d of %d bytes", n, len(sinkInfoReply))
+		}
+	}
+}
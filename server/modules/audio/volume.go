@@ -0,0 +1,127 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+)
+
+// Channel positions (pa_channel_position_t) with a left or right side.
+// Positions 12 to 43 are the AUX channels, which have no side.
+const (
+	channelFrontLeft          = 1
+	channelFrontRight         = 2
+	channelRearLeft           = 5
+	channelRearRight          = 6
+	channelFrontLeftOfCenter  = 8
+	channelFrontRightOfCenter = 9
+	channelSideLeft           = 10
+	channelSideRight          = 11
+	channelTopFrontLeft       = 45
+	channelTopFrontRight      = 46
+	channelTopRearLeft        = 48
+	channelTopRearRight       = 49
+)
+
+// Channel positions on the left and right of the listener.
+var (
+	leftPositions = map[uint8]bool{
+		channelFrontLeft:         true,
+		channelRearLeft:          true,
+		channelFrontLeftOfCenter: true,
+		channelSideLeft:          true,
+		channelTopFrontLeft:      true,
+		channelTopRearLeft:       true,
+	}
+	rightPositions = map[uint8]bool{
+		channelFrontRight:         true,
+		channelRearRight:          true,
+		channelFrontRightOfCenter: true,
+		channelSideRight:          true,
+		channelTopFrontRight:      true,
+		channelTopRearRight:       true,
+	}
+)
+
+// ScaleVolume sets the loudest channel to percent and scales the others by
+// the same factor, keeping the balance (pa_cvolume_scale).
+func ScaleVolume(vols []uint32, percent int) []uint32 {
+	peak := maxVolume(vols)
+	if peak == 0 {
+		return UniformVolume(len(vols), percent)
+	}
+	if percent < 0 {
+		percent = 0
+	}
+	target := float64(percent) * VolumeNorm / 100
+	scaled := make([]uint32, len(vols))
+	for i, v := range vols {
+		scaled[i] = uint32(math.Round(float64(v) * target / float64(peak)))
+	}
+	return scaled
+}
+
+// Balance returns the left/right balance from -1 (left) to 1 (right), or 0
+// when the channel map has no stereo pair.
+func Balance(vols []uint32, channelMap []uint8) float64 {
+	var left, right uint32
+	for i, pos := range channelMap {
+		if i >= len(vols) {
+			break
+		}
+		if leftPositions[pos] && vols[i] > left {
+			left = vols[i]
+		}
+		if rightPositions[pos] && vols[i] > right {
+			right = vols[i]
+		}
+	}
+	switch {
+	case left == right:
+		return 0
+	case left > right:
+		return -(1 - float64(right)/float64(left))
+	default:
+		return 1 - float64(left)/float64(right)
+	}
+}
+
+// SetBalance attenuates the quieter side so that the loudest channel keeps
+// its volume (pa_cvolume_set_balance).
+func SetBalance(vols []uint32, channelMap []uint8, balance float64) ([]uint32, error) {
+	if balance < -1 {
+		balance = -1
+	}
+	if balance > 1 {
+		balance = 1
+	}
+
+	hasLeft, hasRight := false, false
+	for _, pos := range channelMap {
+		hasLeft = hasLeft || leftPositions[pos]
+		hasRight = hasRight || rightPositions[pos]
+	}
+	if !hasLeft || !hasRight || len(channelMap) != len(vols) {
+		return nil, fmt.Errorf("device has no left/right channels")
+	}
+
+	peak := maxVolume(vols)
+	left, right := float64(peak), float64(peak)
+	if balance < 0 {
+		right *= 1 + balance
+	} else {
+		left *= 1 - balance
+	}
+
+	balanced := make([]uint32, len(vols))
+	for i, pos := range channelMap {
+		switch {
+		case leftPositions[pos]:
+			balanced[i] = uint32(math.Round(left))
+		case rightPositions[pos]:
+			balanced[i] = uint32(math.Round(right))
+		default:
+			balanced[i] = peak
+		}
+	}
+	return balanced, nil
+}
@@ -0,0 +1,153 @@
+package camera
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unicode"
+	"unsafe"
+)
+
+const (
+	// _IOR('V', 0, struct v4l2_capability)
+	vidiocQueryCap = 0x80685600
+
+	capVideoCapture       = 0x00000001
+	capVideoCaptureMplane = 0x00001000
+	capDeviceCaps         = 0x80000000
+
+	busUSB        = "usb"
+	busIntegrated = "integrated"
+	busIR         = "ir"
+)
+
+// v4l2Capability mirrors struct v4l2_capability.
+type v4l2Capability struct {
+	Driver       [16]byte
+	Card         [32]byte
+	BusInfo      [32]byte
+	Version      uint32
+	Capabilities uint32
+	DeviceCaps   uint32
+	Reserved     [3]uint32
+}
+
+// cameraDevice is a V4L2 node and the physical camera it belongs to.
+type cameraDevice struct {
+	Path string
+	// Name is the kernel's name for the node.
+	Name    string
+	Capture bool
+	// Product names the physical camera, e.g. "HD Pro Webcam C920".
+	Product string
+	Bus     string
+	// Physical is the sysfs path of the device the node hangs off; nodes
+	// of one camera share it.
+	Physical string
+}
+
+// probeDevice identifies a node from VIDIOC_QUERYCAP and sysfs.
+func probeDevice(path string) cameraDevice {
+	dev := cameraDevice{Path: path, Name: deviceName(path), Physical: path}
+	sysfs := filepath.Join("/sys/class", videoSubsystem, filepath.Base(path))
+
+	card := ""
+	if caps, err := queryCap(path); err == nil {
+		card = cString(caps.Card[:])
+		c := caps.Capabilities
+		if c&capDeviceCaps != 0 {
+			c = caps.DeviceCaps
+		}
+		dev.Capture = c&(capVideoCapture|capVideoCaptureMplane) != 0
+	} else {
+		// Without access to the node, fall back to the convention that a
+		// camera's capture node is its first one; metadata nodes follow.
+		index, err := readAttr(sysfs, "index")
+		dev.Capture = err != nil || index == "0"
+	}
+
+	dev.Bus = busIntegrated
+	if physical, err := filepath.EvalSymlinks(filepath.Join(sysfs, "device")); err == nil {
+		dev.Physical = physical
+
+		// USB interfaces are named like 1-1:1.0 below the USB device
+		usb := filepath.Dir(physical)
+		if _, err := readAttr(usb, "idVendor"); err == nil && strings.Contains(filepath.Base(physical), ":") {
+			dev.Product, _ = readAttr(usb, "product")
+			// Built-in webcams hang off internal USB ports and report fixed
+			// or unknown, so only a port marked removable counts as an
+			// external camera; everything else stays integrated.
+			if removable, _ := readAttr(usb, "removable"); removable == "removable" {
+				dev.Bus = busUSB
+			}
+		}
+	}
+
+	if dev.Product == "" {
+		dev.Product = card
+	}
+	if dev.Product == "" {
+		dev.Product = dev.Name
+	}
+	if isInfrared(dev.Name) || isInfrared(dev.Product) {
+		dev.Bus = busIR
+	}
+	return dev
+}
+
+func queryCap(path string) (v4l2Capability, error) {
+	var caps v4l2Capability
+	fd, err := syscall.Open(path, syscall.O_RDONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return caps, err
+	}
+	defer syscall.Close(fd)
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), vidiocQueryCap, uintptr(unsafe.Pointer(&caps)))
+	if errno != 0 {
+		return caps, errno
+	}
+	return caps, nil
+}
+
+// isInfrared spots IR cameras used for face unlock, which name themselves
+// like "Integrated_Webcam_IR" or "IR Camera".
+func isInfrared(name string) bool {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if strings.EqualFold(word, "ir") || strings.EqualFold(word, "infrared") {
+			return true
+		}
+	}
+	return false
+}
+
+func readAttr(dir, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// deviceName reads the driver-provided name from sysfs, falling back to the
+// node name.
+func deviceName(device string) string {
+	base := filepath.Base(device)
+	name, err := readAttr(filepath.Join("/sys/class", videoSubsystem, base), "name")
+	if err != nil || name == "" {
+		return base
+	}
+	return name
+}
@@ -0,0 +1,222 @@
+package camera
+
+import (
+	"bytes"
+	"dynamic-island-server/core"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	videoSubsystem = "video4linux"
+
+	watchAttempts   = 5
+	watchRetryDelay = 500 * time.Millisecond
+)
+
+// uevent is a kernel device event.
+type uevent struct {
+	Action    string
+	Subsystem string
+	// DevName is relative to /dev, e.g. "video0".
+	DevName string
+}
+
+// ueventListener reads kernel uevents from a netlink socket.
+type ueventListener struct {
+	file *os.File
+}
+
+func newUeventListener() (*ueventListener, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, err
+	}
+	// Group 1 carries the kernel's own messages; udev rebroadcasts on group 2
+	// in a different format.
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: 1}); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	// Non-blocking so the runtime poller can interrupt Read on Close
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return &ueventListener{file: os.NewFile(uintptr(fd), "uevent")}, nil
+}
+
+// Read blocks until the next uevent. It returns an error once closed.
+func (l *ueventListener) Read() (uevent, error) {
+	buf := make([]byte, 8192)
+	for {
+		n, err := l.file.Read(buf)
+		if err == syscall.ENOBUFS {
+			// Receive queue overflowed during a burst; later events still arrive
+			continue
+		}
+		if err != nil {
+			return uevent{}, err
+		}
+		if ev, ok := parseUevent(buf[:n]); ok {
+			return ev, nil
+		}
+	}
+}
+
+func (l *ueventListener) Close() error {
+	return l.file.Close()
+}
+
+// parseUevent decodes "action@devpath\0KEY=value\0...".
+func parseUevent(msg []byte) (uevent, bool) {
+	fields := bytes.Split(msg, []byte{0})
+	if len(fields) < 2 || !bytes.Contains(fields[0], []byte("@")) {
+		return uevent{}, false
+	}
+
+	var ev uevent
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(string(field), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "ACTION":
+			ev.Action = value
+		case "SUBSYSTEM":
+			ev.Subsystem = value
+		case "DEVNAME":
+			ev.DevName = value
+		}
+	}
+	return ev, ev.Action != ""
+}
+
+// watchHotplug adds and removes device watches as cameras come and go.
+func (s *CameraSource) watchHotplug(bus core.Bus, stopChan <-chan struct{}) {
+	listener, err := newUeventListener()
+	if err != nil {
+		// log.Printf("Failed to listen for camera hotplug: %v", err)
+		return
+	}
+	go func() {
+		<-stopChan
+		listener.Close()
+	}()
+
+	for {
+		ev, err := listener.Read()
+		if err != nil {
+			return
+		}
+		if ev.Subsystem != videoSubsystem || ev.DevName == "" {
+			continue
+		}
+
+		device := "/dev/" + ev.DevName
+		switch ev.Action {
+		case "add":
+			s.addDevice(bus, device, true)
+		case "remove":
+			s.removeDevice(bus, device)
+		}
+	}
+}
+
+// addDevice starts watching a capture node. Metadata-only nodes are ignored,
+// and a camera is announced once however many capture nodes it has.
+func (s *CameraSource) addDevice(bus core.Bus, path string, announce bool) {
+	dev := probeDevice(path)
+	if !dev.Capture {
+		return
+	}
+
+	s.devMu.Lock()
+	_, known := s.devices[path]
+	sibling := s.hasNodeOf(dev.Physical, path)
+	s.devices[path] = dev
+	s.devMu.Unlock()
+
+	if s.watcher != nil {
+		go s.addWatch(path)
+	}
+
+	if announce && !known && !sibling {
+		bus.Publish(newDeviceEvent(core.EventCameraConnected, dev))
+	}
+}
+
+// addWatch retries for a while since the kernel announces a node before
+// udev has granted the session access to it.
+func (s *CameraSource) addWatch(device string) {
+	for attempt := 0; attempt < watchAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(watchRetryDelay)
+		}
+
+		s.devMu.Lock()
+		_, present := s.devices[device]
+		s.devMu.Unlock()
+		if !present {
+			return
+		}
+
+		if err := s.watcher.Add(device); err == nil {
+			return
+		}
+	}
+	// log.Printf("Failed to watch %s", device)
+}
+
+func (s *CameraSource) removeDevice(bus core.Bus, path string) {
+	s.devMu.Lock()
+	dev, known := s.devices[path]
+	delete(s.devices, path)
+	sibling := s.hasNodeOf(dev.Physical, path)
+	s.devMu.Unlock()
+
+	if !known {
+		return
+	}
+	if s.watcher != nil {
+		// The kernel already dropped the watch with the node
+		s.watcher.Remove(path)
+	}
+
+	if !sibling {
+		bus.Publish(newDeviceEvent(core.EventCameraDisconnected, dev))
+	}
+	// Apps still holding the node stop using the camera now
+	s.requestCheck()
+}
+
+// hasNodeOf reports whether another watched node belongs to the same
+// camera. Caller must hold s.devMu.
+func (s *CameraSource) hasNodeOf(physical, except string) bool {
+	for path, dev := range s.devices {
+		if path != except && dev.Physical == physical {
+			return true
+		}
+	}
+	return false
+}
+
+// captureDevice returns the watched capture node at path.
+func (s *CameraSource) captureDevice(path string) (cameraDevice, bool) {
+	s.devMu.Lock()
+	defer s.devMu.Unlock()
+	dev, ok := s.devices[path]
+	return dev, ok
+}
+
+func newDeviceEvent(eventType core.EventType, dev cameraDevice) *core.Event {
+	event := core.NewEvent(eventType, dev.Product, 0)
+	event.Metadata["device"] = "camera"
+	event.Metadata["device_path"] = dev.Path
+	event.Metadata["name"] = dev.Product
+	event.Metadata["bus"] = dev.Bus
+	return event
+}
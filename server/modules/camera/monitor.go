@@ -2,8 +2,8 @@ package camera
 
 import (
 	"dynamic-island-server/core"
+	"dynamic-island-server/modules/pipewire"
 	"fmt"
-	"os/exec"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,11 +15,24 @@ import (
 type CameraSource struct {
 	activeApps map[string]bool
 	mu         sync.Mutex
+
+	// devices holds the watched capture nodes by path.
+	devMu   sync.Mutex
+	devices map[string]cameraDevice
+	watcher *fsnotify.Watcher
+
+	pwMu     sync.Mutex
+	pipewire *pipewire.Conn
+
+	// changed coalesces requests to rescan camera users.
+	changed chan struct{}
 }
 
 func NewCameraSource() *CameraSource {
 	return &CameraSource{
 		activeApps: make(map[string]bool),
+		devices:    make(map[string]cameraDevice),
+		changed:    make(chan struct{}, 1),
 	}
 }
 
@@ -29,17 +42,24 @@ func (s *CameraSource) GetName() string {
 
 func (s *CameraSource) Start(bus core.Bus, stopChan <-chan struct{}) error {
 
-	s.checkAndPublish(bus)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		// log.Printf("Failed to create fsnotify watcher: %v", err)
+	} else {
+		s.watcher = watcher
+		go s.watchWithInotify(stopChan)
+	}
 
-	videoDevices := s.findVideoDevices()
-	if len(videoDevices) == 0 {
-		// log.Println("⚠️  No video devices found, camera monitoring disabled")
-		return nil
+	// Cameras present at start-up are watched silently
+	for _, device := range findVideoDevices() {
+		s.addDevice(bus, device, false)
 	}
 
-	// log.Printf("📹 Monitoring %d video devices: %v", len(videoDevices), videoDevices)
+	s.checkAndPublish(bus)
 
-	go s.watchWithInotify(videoDevices, bus, stopChan)
+	go s.watchHotplug(bus, stopChan)
+	go s.watchPipeWire(stopChan)
+	go s.processChanges(bus, stopChan)
 
 	ticker := time.NewTicker(10 * time.Second)
 	go func() {
@@ -57,95 +77,78 @@ func (s *CameraSource) Start(bus core.Bus, stopChan <-chan struct{}) error {
 	return nil
 }
 
-func (s *CameraSource) findVideoDevices() []string {
-	var devices []string
-
-	for i := 0; i < 10; i++ {
-		device := fmt.Sprintf("/dev/video%d", i)
-		if _, err := exec.Command("test", "-e", device).CombinedOutput(); err == nil {
-			devices = append(devices, device)
-		}
+func (s *CameraSource) requestCheck() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
 	}
-
-	return devices
 }
 
-func (s *CameraSource) watchWithInotify(devices []string, bus core.Bus, stopChan <-chan struct{}) {
-
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		// log.Printf("Failed to create fsnotify watcher: %v", err)
-		return
-	}
-	defer watcher.Close()
-
-	for _, device := range devices {
-		if err := watcher.Add(device); err != nil {
-			// log.Printf("Failed to watch %s: %v", device, err)
-		} else {
-			// log.Printf("👁️  Watching: %s", device)
-		}
-	}
+func (s *CameraSource) watchWithInotify(stopChan <-chan struct{}) {
+	defer s.watcher.Close()
 
-	debounce := make(chan struct{}, 1)
+	for {
+		select {
+		case event, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
 
-	go func() {
-		for {
-			select {
-			case event, ok := <-watcher.Events:
-				if !ok {
-					return
-				}
-
-				if event.Op&fsnotify.Write == fsnotify.Write ||
-					event.Op&fsnotify.Create == fsnotify.Create ||
-					event.Op&fsnotify.Chmod == fsnotify.Chmod {
-
-					select {
-					case debounce <- struct{}{}:
-					default:
-					}
-				}
-
-			case _, ok := <-watcher.Errors:
-				if !ok {
-					return
-				}
-				// log.Printf("fsnotify error: %v", err)
+			if event.Op&fsnotify.Write == fsnotify.Write ||
+				event.Op&fsnotify.Create == fsnotify.Create ||
+				event.Op&fsnotify.Chmod == fsnotify.Chmod {
+				s.requestCheck()
+			}
 
-			case <-stopChan:
+		case _, ok := <-s.watcher.Errors:
+			if !ok {
 				return
 			}
+			// log.Printf("fsnotify error: %v", err)
+
+		case <-stopChan:
+			return
 		}
-	}()
+	}
+}
 
-	go func() {
-		for {
-			select {
-			case <-debounce:
+func (s *CameraSource) processChanges(bus core.Bus, stopChan <-chan struct{}) {
+	for {
+		select {
+		case <-s.changed:
 
-				time.Sleep(200 * time.Millisecond)
+			time.Sleep(200 * time.Millisecond)
 
-				for len(debounce) > 0 {
-					<-debounce
-				}
+			for len(s.changed) > 0 {
+				<-s.changed
+			}
 
-				s.checkAndPublish(bus)
+			s.checkAndPublish(bus)
 
-			case <-stopChan:
-				return
-			}
+		case <-stopChan:
+			return
 		}
-	}()
-
-	<-stopChan
+	}
 }
 
 func (s *CameraSource) checkAndPublish(bus core.Bus) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	current := getCameraApps()
+	current := getCameraApps(func(path string) bool {
+		_, ok := s.captureDevice(path)
+		return ok
+	})
+	if pw := s.pipewireConn(); pw != nil {
+		// Attribute nodes PipeWire holds open to the apps it streams to
+		apps := pipewireCameraApps(pw)
+		for _, app := range current {
+			if !pipewireProcesses[app.AppName] {
+				apps = append(apps, app)
+			}
+		}
+		current = apps
+	}
 	currentMap := make(map[string]bool)
 
 	for _, app := range current {
@@ -159,6 +162,15 @@ func (s *CameraSource) checkAndPublish(bus core.Bus) {
 			if app.DevicePath != "" {
 				event.Metadata["device_path"] = app.DevicePath
 			}
+			if dev, ok := s.captureDevice(app.DevicePath); ok {
+				event.Metadata["camera"] = dev.Product
+				event.Metadata["bus"] = dev.Bus
+			} else if app.Camera != "" {
+				event.Metadata["camera"] = app.Camera
+			}
+			if app.AppID != "" {
+				event.Metadata["app_id"] = app.AppID
+			}
 			bus.Publish(event)
 		}
 	}
@@ -182,67 +194,8 @@ type AppInfo struct {
 	AppName    string
 	PID        int
 	DevicePath string
-}
-
-func getCameraApps() []AppInfo {
-	var apps []AppInfo
-
-	videoDevices := []string{"/dev/video0", "/dev/video1", "/dev/video2", "/dev/video3"}
-
-	for _, device := range videoDevices {
-
-		if _, err := exec.Command("test", "-e", device).Output(); err != nil {
-			continue
-		}
-
-		cmd := exec.Command("lsof", "-t", device)
-		output, err := cmd.Output()
-		if err != nil {
-			continue
-		}
-
-		pids := strings.Split(strings.TrimSpace(string(output)), "\n")
-		for _, pidStr := range pids {
-			if pidStr == "" {
-				continue
-			}
-
-			pid, err := strconv.Atoi(pidStr)
-			if err != nil {
-				continue
-			}
-
-			commPath := fmt.Sprintf("/proc/%d/comm", pid)
-			cmd := exec.Command("cat", commPath)
-			if out, err := cmd.Output(); err == nil {
-				appName := strings.TrimSpace(string(out))
-
-				cmdlinePath := fmt.Sprintf("/proc/%d/cmdline", pid)
-				cmd := exec.Command("cat", cmdlinePath)
-				if cmdlineOut, err := cmd.Output(); err == nil {
-					cmdline := strings.ReplaceAll(string(cmdlineOut), "\x00", " ")
-					cmdline = strings.TrimSpace(cmdline)
-
-					if parts := strings.Fields(cmdline); len(parts) > 0 {
-						execName := parts[0]
-						if strings.Contains(execName, "/") {
-							execName = execName[strings.LastIndex(execName, "/")+1:]
-						}
-
-						if execName != "" && execName != appName {
-							appName = execName
-						}
-					}
-				}
-
-				apps = append(apps, AppInfo{
-					AppName:    appName,
-					PID:        pid,
-					DevicePath: device,
-				})
-			}
-		}
-	}
-
-	return apps
+	// AppID is the flatpak app ID of sandboxed apps.
+	AppID string
+	// Camera names the camera when the device node is unknown.
+	Camera string
 }
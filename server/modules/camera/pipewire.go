@@ -0,0 +1,134 @@
+package camera
+
+import (
+	"dynamic-island-server/modules/pipewire"
+	"dynamic-island-server/modules/procinfo"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	pipewireRetryMin = time.Second
+	pipewireRetryMax = 30 * time.Second
+)
+
+// pipewireProcesses open camera nodes on behalf of PipeWire clients, so the
+// fd scan attributes portal camera use to them instead of the app.
+var pipewireProcesses = map[string]bool{
+	"pipewire":               true,
+	"wireplumber":            true,
+	"pipewire-media-session": true,
+}
+
+// watchPipeWire keeps a PipeWire connection open while running, redialling
+// with backoff when the daemon restarts.
+func (s *CameraSource) watchPipeWire(stopChan <-chan struct{}) {
+	backoff := pipewireRetryMin
+	for {
+		conn, err := pipewire.Dial("Dynamic Island", s.requestCheck)
+		if err == nil {
+			s.pwMu.Lock()
+			s.pipewire = conn
+			s.pwMu.Unlock()
+			backoff = pipewireRetryMin
+
+			select {
+			case <-conn.Done():
+			case <-stopChan:
+				conn.Close()
+				return
+			}
+
+			s.pwMu.Lock()
+			s.pipewire = nil
+			s.pwMu.Unlock()
+			s.requestCheck()
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-stopChan:
+			return
+		}
+		backoff = min(backoff*2, pipewireRetryMax)
+	}
+}
+
+func (s *CameraSource) pipewireConn() *pipewire.Conn {
+	s.pwMu.Lock()
+	defer s.pwMu.Unlock()
+	return s.pipewire
+}
+
+// pipewireCameraApps finds the clients whose nodes are linked to a camera
+// source node.
+func pipewireCameraApps(conn *pipewire.Conn) []AppInfo {
+	cameras := make(map[uint32]pipewire.Global)
+	for _, node := range conn.Globals(pipewire.TypeNode) {
+		// Hardware cameras belong to a device; screencasts do not
+		if node.Props["media.class"] == "Video/Source" && node.Props["device.id"] != "" {
+			cameras[node.ID] = node
+		}
+	}
+	if len(cameras) == 0 {
+		return nil
+	}
+
+	self := os.Getpid()
+	var apps []AppInfo
+	for _, link := range conn.Globals(pipewire.TypeLink) {
+		camera, ok := cameras[propID(link.Props, "link.output.node")]
+		if !ok {
+			continue
+		}
+		node, ok := conn.Global(propID(link.Props, "link.input.node"))
+		if !ok {
+			continue
+		}
+		client, ok := conn.Global(propID(node.Props, "client.id"))
+		if !ok {
+			continue
+		}
+
+		app := AppInfo{
+			AppName: client.Props["application.name"],
+			AppID:   client.Props["pipewire.access.portal.app_id"],
+			Camera:  camera.Props["node.description"],
+		}
+		app.PID, _ = strconv.Atoi(client.Props["pipewire.sec.pid"])
+		if app.PID == 0 {
+			app.PID, _ = strconv.Atoi(client.Props["application.process.id"])
+		}
+		if app.PID == self {
+			continue
+		}
+		if app.AppID == "" && app.PID > 0 {
+			app.AppID = procinfo.FlatpakAppID(app.PID)
+		}
+		if app.AppName == "" {
+			app.AppName = app.AppID
+		}
+		if app.AppName == "" && app.PID > 0 {
+			app.AppName = procinfo.Name(app.PID)
+		}
+		if app.AppName == "" {
+			app.AppName = node.Props["node.name"]
+		}
+		if path, ok := strings.CutPrefix(camera.Props["object.path"], "v4l2:"); ok {
+			app.DevicePath = path
+		}
+
+		apps = append(apps, app)
+	}
+	return apps
+}
+
+func propID(props map[string]string, key string) uint32 {
+	id, err := strconv.ParseUint(props[key], 10, 32)
+	if err != nil {
+		return 0xFFFFFFFF
+	}
+	return uint32(id)
+}
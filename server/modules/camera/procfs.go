@@ -0,0 +1,87 @@
+package camera
+
+import (
+	"dynamic-island-server/modules/procinfo"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const videoDevicePrefix = "/dev/video"
+
+// getCameraApps finds processes holding a V4L2 device node accepted by
+// wanted open by walking the /proc/<pid>/fd symlinks. Processes of other
+// users are skipped since their fds are unreadable.
+func getCameraApps(wanted func(path string) bool) []AppInfo {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+
+	self := os.Getpid()
+	var apps []AppInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() || pid == self {
+			continue
+		}
+
+		device := openVideoDevice(pid, wanted)
+		if device == "" {
+			continue
+		}
+		name := procinfo.Name(pid)
+		if name == "" {
+			// Exited while scanning
+			continue
+		}
+		apps = append(apps, AppInfo{
+			AppName:    name,
+			PID:        pid,
+			DevicePath: device,
+		})
+	}
+	return apps
+}
+
+// openVideoDevice returns the first wanted video device node pid has open.
+func openVideoDevice(pid int, wanted func(path string) bool) string {
+	dir := "/proc/" + strconv.Itoa(pid) + "/fd"
+	fds, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	for _, fd := range fds {
+		target, err := os.Readlink(dir + "/" + fd.Name())
+		if err != nil {
+			continue
+		}
+		if isVideoDevice(target) && wanted(target) {
+			return target
+		}
+	}
+	return ""
+}
+
+func isVideoDevice(path string) bool {
+	if !strings.HasPrefix(path, videoDevicePrefix) {
+		return false
+	}
+	_, err := strconv.Atoi(path[len(videoDevicePrefix):])
+	return err == nil
+}
+
+// findVideoDevices lists the V4L2 device nodes in /dev.
+func findVideoDevices() []string {
+	matches, _ := filepath.Glob(videoDevicePrefix + "*")
+
+	var devices []string
+	for _, path := range matches {
+		if isVideoDevice(path) {
+			devices = append(devices, path)
+		}
+	}
+	return devices
+}
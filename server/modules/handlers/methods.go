@@ -4,6 +4,9 @@ import (
 	"dynamic-island-server/modules/battery"
 	"dynamic-island-server/modules/brightness"
 	"dynamic-island-server/modules/media"
+	"dynamic-island-server/modules/microphone"
+	"dynamic-island-server/modules/privacy"
+	"dynamic-island-server/modules/spectrum"
 	"dynamic-island-server/modules/volume"
 	"fmt"
 
@@ -15,14 +18,20 @@ type ServerMethods struct {
 	brightnessService *brightness.BrightnessService
 	volumeService     *volume.VolumeService
 	mediaService      *media.MediaService
+	microphoneService *microphone.MicrophoneService
+	spectrumService   *spectrum.SpectrumService
+	privacyService    *privacy.PrivacyService
 }
 
-func NewServerMethods(batteryService *battery.BatteryService, brightnessService *brightness.BrightnessService, volumeService *volume.VolumeService, mediaService *media.MediaService) *ServerMethods {
+func NewServerMethods(batteryService *battery.BatteryService, brightnessService *brightness.BrightnessService, volumeService *volume.VolumeService, mediaService *media.MediaService, microphoneService *microphone.MicrophoneService, spectrumService *spectrum.SpectrumService, privacyService *privacy.PrivacyService) *ServerMethods {
 	return &ServerMethods{
 		batteryService:    batteryService,
 		brightnessService: brightnessService,
 		volumeService:     volumeService,
 		mediaService:      mediaService,
+		microphoneService: microphoneService,
+		spectrumService:   spectrumService,
+		privacyService:    privacyService,
 	}
 }
 
@@ -33,6 +42,29 @@ func (m *ServerMethods) SetVolume(level int32) *dbus.Error {
 	return nil
 }
 
+func (m *ServerMethods) VolumeUp(step int32) (level int32, err *dbus.Error) {
+	l, e := m.volumeService.VolumeUp(step)
+	if e != nil {
+		return 0, dbus.MakeFailedError(e)
+	}
+	return l, nil
+}
+
+func (m *ServerMethods) VolumeDown(step int32) (level int32, err *dbus.Error) {
+	l, e := m.volumeService.VolumeDown(step)
+	if e != nil {
+		return 0, dbus.MakeFailedError(e)
+	}
+	return l, nil
+}
+
+func (m *ServerMethods) SetVolumeBalance(balance float64) *dbus.Error {
+	if err := m.volumeService.SetVolumeBalance(balance); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
 func (m *ServerMethods) ToggleMute() *dbus.Error {
 	if err := m.volumeService.ToggleMute(); err != nil {
 		return dbus.MakeFailedError(err)
@@ -40,6 +72,86 @@ func (m *ServerMethods) ToggleMute() *dbus.Error {
 	return nil
 }
 
+func (m *ServerMethods) ListAudioOutputs() (outputs string, err *dbus.Error) {
+	o, e := m.volumeService.ListAudioOutputs()
+	if e != nil {
+		return "", dbus.MakeFailedError(e)
+	}
+	return o, nil
+}
+
+func (m *ServerMethods) SetDefaultAudioOutput(name string, moveStreams bool) *dbus.Error {
+	if err := m.volumeService.SetDefaultAudioOutput(name, moveStreams); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (m *ServerMethods) ListAudioInputs() (inputs string, err *dbus.Error) {
+	i, e := m.volumeService.ListAudioInputs()
+	if e != nil {
+		return "", dbus.MakeFailedError(e)
+	}
+	return i, nil
+}
+
+func (m *ServerMethods) SetDefaultAudioInput(name string, moveStreams bool) *dbus.Error {
+	if err := m.volumeService.SetDefaultAudioInput(name, moveStreams); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (m *ServerMethods) ListAudioStreams() (streams string, err *dbus.Error) {
+	s, e := m.volumeService.ListAudioStreams()
+	if e != nil {
+		return "", dbus.MakeFailedError(e)
+	}
+	return s, nil
+}
+
+func (m *ServerMethods) SetStreamVolume(id uint32, level int32) *dbus.Error {
+	if err := m.volumeService.SetStreamVolume(id, level); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (m *ServerMethods) SetStreamMute(id uint32, muted bool) *dbus.Error {
+	if err := m.volumeService.SetStreamMute(id, muted); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (m *ServerMethods) ToggleMicMute() (muted bool, err *dbus.Error) {
+	mu, e := m.microphoneService.ToggleMicMute()
+	if e != nil {
+		return mu, dbus.MakeFailedError(e)
+	}
+	return mu, nil
+}
+
+func (m *ServerMethods) SetMicVolume(level int32) *dbus.Error {
+	if err := m.microphoneService.SetMicVolume(level); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (m *ServerMethods) GetMicrophoneDiagnostics() (string, *dbus.Error) {
+	d, e := m.microphoneService.GetMicrophoneDiagnostics()
+	if e != nil {
+		return "", dbus.MakeFailedError(e)
+	}
+	return d, nil
+}
+
+func (m *ServerMethods) SetSpectrumActive(active bool) *dbus.Error {
+	m.spectrumService.SetSpectrumActive(active)
+	return nil
+}
+
 func (m *ServerMethods) SetBrightness(level int32) *dbus.Error {
 	if err := m.brightnessService.SetBrightness(level); err != nil {
 		return dbus.MakeFailedError(fmt.Errorf("failed to set brightness: %v", err))
@@ -88,3 +200,106 @@ func (m *ServerMethods) GetMediaInfo() (player string, status string, title stri
 	}
 	return p, s, t, a, u, nil
 }
+
+func (m *ServerMethods) GetLyrics() (lyrics string, err *dbus.Error) {
+	if m.mediaService == nil {
+		return "", dbus.MakeFailedError(fmt.Errorf("media service not available"))
+	}
+	l, e := m.mediaService.GetLyrics()
+	if e != nil {
+		return "", dbus.MakeFailedError(e)
+	}
+	return l, nil
+}
+
+func (m *ServerMethods) GetRecentTracks(count int32) (tracks string, err *dbus.Error) {
+	if m.mediaService == nil {
+		return "", dbus.MakeFailedError(fmt.Errorf("media service not available"))
+	}
+	t, e := m.mediaService.GetRecentTracks(int(count))
+	if e != nil {
+		return "", dbus.MakeFailedError(e)
+	}
+	return t, nil
+}
+
+func (m *ServerMethods) ExportScrobblerLog(name string) (written string, count int32, err *dbus.Error) {
+	if m.mediaService == nil {
+		return "", 0, dbus.MakeFailedError(fmt.Errorf("media service not available"))
+	}
+	w, c, e := m.mediaService.ExportScrobblerLog(name)
+	if e != nil {
+		return "", 0, dbus.MakeFailedError(fmt.Errorf("failed to export scrobbler log: %v", e))
+	}
+	return w, int32(c), nil
+}
+
+func (m *ServerMethods) GetTrackList() (tracks string, err *dbus.Error) {
+	if m.mediaService == nil {
+		return "", dbus.MakeFailedError(fmt.Errorf("media service not available"))
+	}
+	t, e := m.mediaService.GetTrackList()
+	if e != nil {
+		return "", dbus.MakeFailedError(e)
+	}
+	return t, nil
+}
+
+func (m *ServerMethods) GoToTrack(id string) *dbus.Error {
+	if err := m.mediaService.GoToTrack(id); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (m *ServerMethods) GetPlaylists() (playlists string, err *dbus.Error) {
+	if m.mediaService == nil {
+		return "", dbus.MakeFailedError(fmt.Errorf("media service not available"))
+	}
+	p, e := m.mediaService.GetPlaylists()
+	if e != nil {
+		return "", dbus.MakeFailedError(e)
+	}
+	return p, nil
+}
+
+func (m *ServerMethods) ActivatePlaylist(id string) *dbus.Error {
+	if err := m.mediaService.ActivatePlaylist(id); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (m *ServerMethods) MediaSetVolume(level int32) *dbus.Error {
+	if err := m.mediaService.SetVolume(level); err != nil {
+		return dbus.MakeFailedError(fmt.Errorf("failed to set player volume: %v", err))
+	}
+	return nil
+}
+
+func (m *ServerMethods) MediaGetVolume() (level int32, err *dbus.Error) {
+	if m.mediaService == nil {
+		return 0, dbus.MakeFailedError(fmt.Errorf("media service not available"))
+	}
+	l, e := m.mediaService.GetVolume()
+	if e != nil {
+		return 0, dbus.MakeFailedError(e)
+	}
+	return l, nil
+}
+
+func (m *ServerMethods) GetPrivacyHistory(since int64) (history string, err *dbus.Error) {
+	h, e := m.privacyService.GetPrivacyHistory(since)
+	if e != nil {
+		return "", dbus.MakeFailedError(e)
+	}
+	return h, nil
+}
+
+func (m *ServerMethods) GetPrivacySummary() (summary string, err *dbus.Error) {
+	s, e := m.privacyService.GetPrivacySummary()
+	if e != nil {
+		return "", dbus.MakeFailedError(e)
+	}
+	return s, nil
+}
@@ -0,0 +1,474 @@
+package media
+
+import (
+	"container/list"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	artCacheDirName      = "dynamic-island-art"
+	artCacheIndexFile    = "index.json"
+	defaultArtCacheQuota = 64 * 1024 * 1024
+	maxArtDownloadSize   = 8 * 1024 * 1024
+	artCacheSaveDelay    = 2 * time.Second
+)
+
+// artExtensions maps sniffed content types to the extension used on disk.
+var artExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+	"image/bmp":  ".bmp",
+}
+
+type artEntry struct {
+	URL      string    `json:"url"`
+	File     string    `json:"file"`
+	Size     int64     `json:"size"`
+	Type     string    `json:"type"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+type artDownload struct {
+	done chan struct{}
+	path string
+	err  error
+}
+
+// ArtCache stores downloaded album art on disk and evicts the least recently
+// used entries once the total size exceeds the quota.
+type ArtCache struct {
+	dir         string
+	quota       int64
+	maxDownload int64
+	httpClient  *http.Client
+
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List
+	size     int64
+	inflight map[string]*artDownload
+	saveTmr  *time.Timer
+	onEvict  func(path string)
+
+	// flushMu serializes index writes from the save timer and Close.
+	flushMu sync.Mutex
+}
+
+func NewArtCache(dir string, quota int64) *ArtCache {
+	if quota <= 0 {
+		quota = defaultArtCacheQuota
+	}
+
+	c := &ArtCache{
+		dir:         dir,
+		quota:       quota,
+		maxDownload: maxArtDownloadSize,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		entries:     make(map[string]*list.Element),
+		lru:         list.New(),
+		inflight:    make(map[string]*artDownload),
+	}
+	c.loadIndex()
+	return c
+}
+
+// defaultArtCacheDir returns ~/.cache/dynamic-island-art, or "" when the user
+// cache directory cannot be determined.
+func defaultArtCacheDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, artCacheDirName)
+}
+
+// OnEvict registers a callback invoked with the file path of every evicted entry.
+func (c *ArtCache) OnEvict(fn func(path string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onEvict = fn
+}
+
+// Resolve maps an MPRIS art URL (http(s)://, data: or file://) to a local
+// file, fetching or decoding it into the cache when necessary.
+func (c *ArtCache) Resolve(artUrl string) (string, error) {
+	switch {
+	case strings.HasPrefix(artUrl, "http://"), strings.HasPrefix(artUrl, "https://"):
+		return c.Fetch(artUrl)
+	case strings.HasPrefix(artUrl, "data:"):
+		key := dataUrlKey(artUrl)
+		if path, ok := c.Lookup(key); ok {
+			return path, nil
+		}
+		data, err := decodeDataUrl(artUrl, c.maxDownload)
+		if err != nil {
+			return "", err
+		}
+		return c.Store(key, data)
+	default:
+		path := localFilePath(artUrl)
+		if path == "" {
+			return "", fmt.Errorf("unsupported art url: %.64s", artUrl)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("art file not available: %v", err)
+		}
+		return path, nil
+	}
+}
+
+// LookupResolved is the non-blocking counterpart of Resolve: it only returns
+// a path when no download or decoding is required.
+func (c *ArtCache) LookupResolved(artUrl string) (string, bool) {
+	switch {
+	case strings.HasPrefix(artUrl, "http://"), strings.HasPrefix(artUrl, "https://"):
+		return c.Lookup(artUrl)
+	case strings.HasPrefix(artUrl, "data:"):
+		return c.Lookup(dataUrlKey(artUrl))
+	default:
+		path := localFilePath(artUrl)
+		return path, path != ""
+	}
+}
+
+// localFilePath returns the filesystem path for file:// URLs and absolute
+// paths, or "" for anything else.
+func localFilePath(artUrl string) string {
+	if strings.HasPrefix(artUrl, "/") {
+		return artUrl
+	}
+	if !strings.HasPrefix(artUrl, "file://") {
+		return ""
+	}
+	u, err := url.Parse(artUrl)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	return u.Path
+}
+
+// dataUrlKey derives a compact cache key so multi-megabyte data: URIs are
+// not kept in the index.
+func dataUrlKey(artUrl string) string {
+	return fmt.Sprintf("data:%x", md5.Sum([]byte(artUrl)))
+}
+
+func decodeDataUrl(artUrl string, maxSize int64) ([]byte, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(artUrl, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("malformed data url")
+	}
+
+	var data []byte
+	var err error
+	if strings.HasSuffix(header, ";base64") {
+		data, err = base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			data, err = base64.RawStdEncoding.DecodeString(payload)
+		}
+	} else {
+		var unescaped string
+		unescaped, err = url.PathUnescape(payload)
+		data = []byte(unescaped)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data url: %v", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("art exceeds %d bytes", maxSize)
+	}
+	return data, nil
+}
+
+// Lookup returns the cached file for artUrl and marks it as recently used.
+// It does not touch the disk: a file deleted behind the cache's back shows
+// up when it is opened, and the caller then calls Invalidate. The new order
+// is saved with the next change to the index or on Close.
+func (c *ArtCache) Lookup(artUrl string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[artUrl]
+	if !ok {
+		return "", false
+	}
+
+	entry := elem.Value.(*artEntry)
+	entry.LastUsed = time.Now()
+	c.lru.MoveToFront(elem)
+	return filepath.Join(c.dir, entry.File), true
+}
+
+// Invalidate forgets the entry for an art URL whose file turned out to be
+// missing. It reports whether there was one.
+func (c *ArtCache) Invalidate(artUrl string) bool {
+	if strings.HasPrefix(artUrl, "data:") {
+		artUrl = dataUrlKey(artUrl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[artUrl]
+	if !ok {
+		return false
+	}
+	c.removeElement(elem)
+	c.scheduleSave()
+	return true
+}
+
+// Fetch returns the local path for artUrl, downloading it if needed. Concurrent
+// calls for the same artUrl share a single download.
+func (c *ArtCache) Fetch(artUrl string) (string, error) {
+	if path, ok := c.Lookup(artUrl); ok {
+		return path, nil
+	}
+
+	c.mu.Lock()
+	if dl, ok := c.inflight[artUrl]; ok {
+		c.mu.Unlock()
+		<-dl.done
+		return dl.path, dl.err
+	}
+	dl := &artDownload{done: make(chan struct{})}
+	c.inflight[artUrl] = dl
+	c.mu.Unlock()
+
+	dl.path, dl.err = c.download(artUrl)
+
+	c.mu.Lock()
+	delete(c.inflight, artUrl)
+	c.mu.Unlock()
+	close(dl.done)
+
+	return dl.path, dl.err
+}
+
+// Store adds already fetched data for key to the cache.
+func (c *ArtCache) Store(key string, data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := artExtensions[contentType]
+	if !ok {
+		return "", fmt.Errorf("unsupported art content type: %s", contentType)
+	}
+
+	if c.dir == "" {
+		return "", fmt.Errorf("art cache directory not available")
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create art cache directory: %v", err)
+	}
+
+	file := fmt.Sprintf("%x%s", md5.Sum([]byte(key)), ext)
+	path := filepath.Join(c.dir, file)
+
+	tmp, err := os.CreateTemp(c.dir, ".art-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %v", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write art: %v", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to save art: %v", err)
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		old := elem.Value.(*artEntry)
+		if old.File != file {
+			c.removeFile(old)
+		}
+		c.size -= old.Size
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+	entry := &artEntry{
+		URL:      key,
+		File:     file,
+		Size:     int64(len(data)),
+		Type:     contentType,
+		LastUsed: time.Now(),
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += entry.Size
+	c.evict()
+	c.scheduleSave()
+	c.mu.Unlock()
+
+	return path, nil
+}
+
+func (c *ArtCache) download(artUrl string) (string, error) {
+	resp, err := c.httpClient.Get(artUrl)
+	if err != nil {
+		return "", fmt.Errorf("failed to download art: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download art: status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > c.maxDownload {
+		return "", fmt.Errorf("art too large: %d bytes", resp.ContentLength)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.maxDownload+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read art: %v", err)
+	}
+	if int64(len(data)) > c.maxDownload {
+		return "", fmt.Errorf("art exceeds %d bytes", c.maxDownload)
+	}
+
+	return c.Store(artUrl, data)
+}
+
+// evict drops least recently used entries until the cache fits the quota.
+// The most recent entry is always kept. Caller must hold c.mu.
+func (c *ArtCache) evict() {
+	for c.size > c.quota && c.lru.Len() > 1 {
+		elem := c.lru.Back()
+		c.removeFile(elem.Value.(*artEntry))
+		c.removeElement(elem)
+	}
+}
+
+// removeFile deletes the file backing entry. Caller must hold c.mu.
+func (c *ArtCache) removeFile(entry *artEntry) {
+	path := filepath.Join(c.dir, entry.File)
+	os.Remove(path)
+	if c.onEvict != nil {
+		go c.onEvict(path)
+	}
+}
+
+// removeElement drops elem from the index. Caller must hold c.mu.
+func (c *ArtCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*artEntry)
+	c.lru.Remove(elem)
+	delete(c.entries, entry.URL)
+	c.size -= entry.Size
+}
+
+func (c *ArtCache) loadIndex() {
+	if c.dir == "" {
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.dir, artCacheIndexFile))
+	if err != nil {
+		return
+	}
+
+	var entries []*artEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		// log.Printf("⚠️ ArtCache: Ignoring corrupt index: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Index is stored most recent first.
+	for _, entry := range entries {
+		if entry.URL == "" || strings.ContainsRune(entry.File, os.PathSeparator) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(c.dir, entry.File))
+		if err != nil {
+			continue
+		}
+		if _, exists := c.entries[entry.URL]; exists {
+			continue
+		}
+		entry.Size = info.Size()
+		c.entries[entry.URL] = c.lru.PushBack(entry)
+		c.size += entry.Size
+	}
+	c.evict()
+}
+
+// scheduleSave coalesces index writes. Caller must hold c.mu.
+func (c *ArtCache) scheduleSave() {
+	if c.saveTmr != nil {
+		return
+	}
+	c.saveTmr = time.AfterFunc(artCacheSaveDelay, func() {
+		c.mu.Lock()
+		c.saveTmr = nil
+		c.mu.Unlock()
+		c.Flush()
+	})
+}
+
+// Close stops the pending save and writes the index one last time.
+func (c *ArtCache) Close() error {
+	c.mu.Lock()
+	if c.saveTmr != nil {
+		c.saveTmr.Stop()
+		c.saveTmr = nil
+	}
+	c.mu.Unlock()
+
+	return c.Flush()
+}
+
+// Flush writes the index to disk immediately.
+func (c *ArtCache) Flush() error {
+	if c.dir == "" {
+		return nil
+	}
+
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	entries := make([]artEntry, 0, c.lru.Len())
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, *elem.Value.(*artEntry))
+	}
+	c.mu.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode art index: %v", err)
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create art cache directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".index-*")
+	if err != nil {
+		return fmt.Errorf("failed to create art index: %v", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write art index: %v", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, artCacheIndexFile)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save art index: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package media
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	historyFileName      = "history.jsonl"
+	scrobblerLogFileName = ".scrobbler.log"
+	exportDirName        = "exports"
+	maxRecentPlays       = 500
+	scrobbleMinLength    = 30 * time.Second
+	scrobbleMaxWait      = 4 * time.Minute
+)
+
+// Play is one listened track as stored in the history file.
+type Play struct {
+	Player        string `json:"player"`
+	Title         string `json:"title"`
+	Artist        string `json:"artist"`
+	Album         string `json:"album"`
+	TrackNumber   int    `json:"trackNumber,omitempty"`
+	MusicBrainzID string `json:"musicBrainzId,omitempty"`
+	Length        int64  `json:"length"`
+	StartedAt     int64  `json:"startedAt"`
+	Listened      int64  `json:"listened"`
+}
+
+type playSession struct {
+	key       string
+	play      Play
+	length    time.Duration
+	listened  time.Duration
+	playingAt time.Time
+}
+
+// ListeningHistory records plays that pass the usual scrobbling rules:
+// the track is longer than 30s and was listened to for half its length or
+// four minutes, whichever comes first.
+type ListeningHistory struct {
+	path    string
+	enabled bool
+
+	mu      sync.Mutex
+	current *playSession
+	recent  []Play
+}
+
+func NewListeningHistory(dir string, enabled bool) *ListeningHistory {
+	h := &ListeningHistory{enabled: enabled}
+	if dir != "" {
+		h.path = filepath.Join(dir, historyFileName)
+	}
+	if enabled {
+		h.load()
+	}
+	return h
+}
+
+// Observe updates the play session for the current track. A new key closes
+// the previous session.
+func (h *ListeningHistory) Observe(key string, play Play, length time.Duration, status string) {
+	if !h.enabled {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if h.current != nil && h.current.key != key {
+		h.finishLocked(now)
+	}
+
+	if h.current == nil {
+		if play.Title == "" {
+			return
+		}
+		play.StartedAt = now.Unix()
+		h.current = &playSession{key: key, play: play, length: length}
+	}
+
+	cur := h.current
+	if length > 0 {
+		cur.length = length
+	}
+
+	isPlaying := status == "Playing"
+	if isPlaying && cur.playingAt.IsZero() {
+		cur.playingAt = now
+	} else if !isPlaying && !cur.playingAt.IsZero() {
+		cur.listened += now.Sub(cur.playingAt)
+		cur.playingAt = time.Time{}
+	}
+}
+
+// Finish closes the current session, e.g. when the player goes away.
+func (h *ListeningHistory) Finish() {
+	if !h.enabled {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.finishLocked(time.Now())
+}
+
+// finishLocked records the current session if it counts as a play. Caller
+// must hold h.mu.
+func (h *ListeningHistory) finishLocked(now time.Time) {
+	cur := h.current
+	h.current = nil
+	if cur == nil {
+		return
+	}
+
+	listened := cur.listened
+	if !cur.playingAt.IsZero() {
+		listened += now.Sub(cur.playingAt)
+	}
+	if !countsAsPlay(cur.length, listened) {
+		return
+	}
+
+	play := cur.play
+	play.Length = int64(cur.length / time.Second)
+	play.Listened = int64(listened / time.Second)
+
+	h.recent = append(h.recent, play)
+	if len(h.recent) > maxRecentPlays {
+		h.recent = h.recent[len(h.recent)-maxRecentPlays:]
+	}
+
+	if err := h.appendToFile(play); err != nil {
+		// log.Printf("⚠️ ListeningHistory: %v", err)
+	}
+}
+
+func countsAsPlay(length, listened time.Duration) bool {
+	if length > 0 && length <= scrobbleMinLength {
+		return false
+	}
+	if listened >= scrobbleMaxWait {
+		return true
+	}
+	return length > 0 && listened >= length/2
+}
+
+// Recent returns up to n plays, newest first.
+func (h *ListeningHistory) Recent(n int) []Play {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if n <= 0 || n > len(h.recent) {
+		n = len(h.recent)
+	}
+	plays := make([]Play, 0, n)
+	for i := len(h.recent) - 1; i >= 0 && len(plays) < n; i-- {
+		plays = append(plays, h.recent[i])
+	}
+	return plays
+}
+
+// ExportScrobblerLog writes the full history in the Audioscrobbler portable
+// player format (.scrobbler.log v1.1) to the file name in the exports
+// directory next to the history file, .scrobbler.log when name is empty. Any
+// caller on the session bus can ask for an export, so exports get a directory
+// of their own where they cannot replace the history or other stores. It
+// returns the written path and the number of entries.
+func (h *ListeningHistory) ExportScrobblerLog(name string) (string, int, error) {
+	if h.path == "" {
+		return "", 0, fmt.Errorf("history storage not available")
+	}
+	if name == "" {
+		name = scrobblerLogFileName
+	}
+	if filepath.IsAbs(name) || strings.ContainsRune(name, filepath.Separator) || name == "." || name == ".." {
+		return "", 0, fmt.Errorf("invalid export file name %q", name)
+	}
+	path := filepath.Join(filepath.Dir(h.path), exportDirName, name)
+
+	plays, err := h.readAll()
+	if err != nil {
+		return "", 0, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("#AUDIOSCROBBLER/1.1\n")
+	sb.WriteString("#TZ/UTC\n")
+	sb.WriteString("#CLIENT/dynamic-island-server\n")
+	for _, p := range plays {
+		trackNum := ""
+		if p.TrackNumber > 0 {
+			trackNum = strconv.Itoa(p.TrackNumber)
+		}
+		fields := []string{
+			scrobblerField(p.Artist),
+			scrobblerField(p.Album),
+			scrobblerField(p.Title),
+			trackNum,
+			strconv.FormatInt(p.Length, 10),
+			"L",
+			strconv.FormatInt(p.StartedAt, 10),
+			scrobblerField(p.MusicBrainzID),
+		}
+		sb.WriteString(strings.Join(fields, "\t"))
+		sb.WriteString("\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", 0, fmt.Errorf("failed to create export directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return "", 0, fmt.Errorf("failed to write scrobbler log: %v", err)
+	}
+	return path, len(plays), nil
+}
+
+func scrobblerField(s string) string {
+	return strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+func (h *ListeningHistory) appendToFile(play Play) error {
+	if h.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %v", err)
+	}
+
+	data, err := json.Marshal(play)
+	if err != nil {
+		return fmt.Errorf("failed to encode play: %v", err)
+	}
+
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %v", err)
+	}
+	return nil
+}
+
+func (h *ListeningHistory) readAll() ([]Play, error) {
+	f, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history: %v", err)
+	}
+	defer f.Close()
+
+	var plays []Play
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var p Play
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			continue
+		}
+		plays = append(plays, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return plays, fmt.Errorf("failed to read history: %v", err)
+	}
+	return plays, nil
+}
+
+func (h *ListeningHistory) load() {
+	if h.path == "" {
+		return
+	}
+	plays, err := h.readAll()
+	if err != nil {
+		// log.Printf("⚠️ ListeningHistory: %v", err)
+	}
+	if len(plays) > maxRecentPlays {
+		plays = plays[len(plays)-maxRecentPlays:]
+	}
+	h.recent = plays
+}
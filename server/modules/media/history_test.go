@@ -0,0 +1,105 @@
+package media
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountsAsPlay(t *testing.T) {
+	tests := []struct {
+		name     string
+		length   time.Duration
+		listened time.Duration
+		want     bool
+	}{
+		{"30s track is too short", 30 * time.Second, 30 * time.Second, false},
+		{"31s track played half", 31 * time.Second, 15500 * time.Millisecond, true},
+		{"31s track just under half", 31 * time.Second, 15 * time.Second, false},
+		{"half of a 3 minute track", 3 * time.Minute, 90 * time.Second, true},
+		{"just under half", 3 * time.Minute, 90*time.Second - time.Millisecond, false},
+		{"4 minutes of a long track", 20 * time.Minute, 4 * time.Minute, true},
+		{"under 4 minutes of a long track", 20 * time.Minute, 4*time.Minute - time.Second, false},
+		{"unknown length, 4 minutes", 0, 4 * time.Minute, true},
+		{"unknown length, under 4 minutes", 0, 3 * time.Minute, false},
+	}
+	for _, tt := range tests {
+		if got := countsAsPlay(tt.length, tt.listened); got != tt.want {
+			t.Errorf("%s: countsAsPlay(%v, %v) = %v, want %v", tt.name, tt.length, tt.listened, got, tt.want)
+		}
+	}
+}
+
+func TestExportScrobblerLog(t *testing.T) {
+	dir := t.TempDir()
+	h := NewListeningHistory(dir, true)
+	play := Play{
+		Player:        "spotify",
+		Title:         "Title\twith tab",
+		Artist:        "Artist",
+		Album:         "Album",
+		TrackNumber:   3,
+		MusicBrainzID: "b1a9c0e9-d987-4042-ae91-78d6a3267d69",
+		Length:        215,
+		StartedAt:     1700000000,
+		Listened:      200,
+	}
+	if err := h.appendToFile(play); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.appendToFile(Play{Title: "No album", Artist: "Someone", Length: 60, StartedAt: 1700000300}); err != nil {
+		t.Fatal(err)
+	}
+
+	path, count, err := h.ExportScrobblerLog("")
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if want := filepath.Join(dir, exportDirName, scrobblerLogFileName); path != want {
+		t.Errorf("exported to %s, want %s", path, want)
+	}
+	if count != 2 {
+		t.Errorf("exported %d plays, want 2", count)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"#AUDIOSCROBBLER/1.1",
+		"#TZ/UTC",
+		"#CLIENT/dynamic-island-server",
+		"Artist\tAlbum\tTitle with tab\t3\t215\tL\t1700000000\tb1a9c0e9-d987-4042-ae91-78d6a3267d69",
+		"Someone\t\tNo album\t\t60\tL\t1700000300\t",
+		"",
+	}, "\n")
+	if string(data) != want {
+		t.Errorf("exported\n%q\nwant\n%q", data, want)
+	}
+}
+
+func TestExportScrobblerLogNames(t *testing.T) {
+	dir := t.TempDir()
+	h := NewListeningHistory(dir, true)
+	if err := h.appendToFile(Play{Title: "Song", Length: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"../history.jsonl", "/tmp/x.log", "a/b.log", ".", ".."} {
+		if _, _, err := h.ExportScrobblerLog(name); err == nil {
+			t.Errorf("export to %q was accepted", name)
+		}
+	}
+
+	// A name matching a store only lands in the exports directory
+	if _, _, err := h.ExportScrobblerLog(historyFileName); err != nil {
+		t.Fatal(err)
+	}
+	plays, err := h.readAll()
+	if err != nil || len(plays) != 1 || plays[0].Title != "Song" {
+		t.Errorf("history changed by export: %v %v", plays, err)
+	}
+}
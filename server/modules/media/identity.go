@@ -0,0 +1,174 @@
+package media
+
+import (
+	"bufio"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var reInstanceSuffix = regexp.MustCompile(`\.instance[_\d]*$`)
+
+// browserDesktopEntries are desktop entries whose xesam:url points at the
+// page playing media rather than a local file.
+var browserDesktopEntries = []string{
+	"firefox", "chromium", "google-chrome", "brave", "microsoft-edge",
+	"vivaldi", "opera", "epiphany", "librewolf", "zen",
+}
+
+// siteNames gives well known media sites a friendlier label than their host.
+var siteNames = map[string]string{
+	"youtube.com":       "YouTube",
+	"music.youtube.com": "YouTube Music",
+	"open.spotify.com":  "Spotify",
+	"soundcloud.com":    "SoundCloud",
+	"twitch.tv":         "Twitch",
+	"netflix.com":       "Netflix",
+	"vimeo.com":         "Vimeo",
+	"bandcamp.com":      "Bandcamp",
+	"music.apple.com":   "Apple Music",
+	"tidal.com":         "TIDAL",
+	"deezer.com":        "Deezer",
+}
+
+var (
+	desktopIconMu    sync.Mutex
+	desktopIconCache = make(map[string]string)
+)
+
+// playerDisplayName returns the MPRIS Identity, or the bus name without the
+// org.mpris prefix and any per-instance suffix.
+func playerDisplayName(info *playerInfo, playerName string) string {
+	if info != nil && info.identity != "" {
+		return info.identity
+	}
+	name := strings.TrimPrefix(playerName, mprisBusPrefix)
+	name = reInstanceSuffix.ReplaceAllString(name, "")
+	if name == "" {
+		name = "unknown"
+	}
+	return name
+}
+
+// desktopEntryIcon resolves the Icon= key of <entry>.desktop from the XDG
+// application directories. Results are cached, including misses.
+func desktopEntryIcon(entry string) string {
+	if entry == "" || strings.ContainsRune(entry, os.PathSeparator) {
+		return ""
+	}
+
+	desktopIconMu.Lock()
+	icon, ok := desktopIconCache[entry]
+	desktopIconMu.Unlock()
+	if ok {
+		return icon
+	}
+
+	file := strings.TrimSuffix(entry, ".desktop") + ".desktop"
+	for _, dir := range applicationDirs() {
+		if icon = readDesktopIcon(filepath.Join(dir, file)); icon != "" {
+			break
+		}
+	}
+
+	desktopIconMu.Lock()
+	desktopIconCache[entry] = icon
+	desktopIconMu.Unlock()
+	return icon
+}
+
+func applicationDirs() []string {
+	var dirs []string
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+	}
+	if dataHome != "" {
+		dirs = append(dirs,
+			filepath.Join(dataHome, "applications"),
+			filepath.Join(dataHome, "flatpak", "exports", "share", "applications"))
+	}
+
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+	for _, dir := range strings.Split(dataDirs, ":") {
+		if dir != "" {
+			dirs = append(dirs, filepath.Join(dir, "applications"))
+		}
+	}
+
+	return append(dirs,
+		"/var/lib/flatpak/exports/share/applications",
+		"/var/lib/snapd/desktop/applications")
+}
+
+func readDesktopIcon(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	inEntry := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inEntry = line == "[Desktop Entry]"
+			continue
+		}
+		if inEntry && strings.HasPrefix(line, "Icon=") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Icon="))
+		}
+	}
+	return ""
+}
+
+func isBrowserPlayer(info *playerInfo, playerName string) bool {
+	candidates := []string{strings.ToLower(strings.TrimPrefix(playerName, mprisBusPrefix))}
+	if info != nil {
+		candidates = append(candidates, strings.ToLower(info.desktopEntry), strings.ToLower(info.identity))
+	}
+	for _, c := range candidates {
+		for _, browser := range browserDesktopEntries {
+			if c != "" && strings.Contains(c, browser) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// siteFromUrl returns a display name for the site serving an http(s) track URL.
+func siteFromUrl(trackUrl string) string {
+	if !strings.HasPrefix(trackUrl, "http://") && !strings.HasPrefix(trackUrl, "https://") {
+		return ""
+	}
+	u, err := url.Parse(trackUrl)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	// Try the host, then each parent domain, so the longest match wins
+	for domain := host; ; {
+		if name, ok := siteNames[domain]; ok {
+			return name
+		}
+		i := strings.IndexByte(domain, '.')
+		if i < 0 {
+			break
+		}
+		domain = domain[i+1:]
+	}
+	return host
+}
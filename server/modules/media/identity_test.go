@@ -0,0 +1,23 @@
+package media
+
+import "testing"
+
+func TestSiteFromUrl(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.youtube.com/watch?v=x", "YouTube"},
+		{"https://m.youtube.com/watch?v=x", "YouTube"},
+		{"https://music.youtube.com/watch?v=x", "YouTube Music"},
+		{"https://eu.music.youtube.com/watch?v=x", "YouTube Music"},
+		{"https://artist.bandcamp.com/track/x", "Bandcamp"},
+		{"https://example.org/stream.mp3", "example.org"},
+		{"file:///home/user/song.mp3", ""},
+	}
+	for _, tt := range tests {
+		if got := siteFromUrl(tt.url); got != tt.want {
+			t.Errorf("siteFromUrl(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
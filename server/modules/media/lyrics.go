@@ -0,0 +1,301 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"dynamic-island-server/core"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxLyricsFileSize = 1024 * 1024
+
+type LyricLine struct {
+	Time time.Duration
+	Text string
+}
+
+type Lyrics struct {
+	Path  string
+	Raw   string
+	Lines []LyricLine
+}
+
+var (
+	reLrcTimestamp = regexp.MustCompile(`\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	reLrcOffset    = regexp.MustCompile(`(?i)^\[offset:\s*([+-]?\d+)\s*\]`)
+)
+
+// ParseLRC parses the timed lines of an .lrc file. Lines may carry several
+// timestamps; an [offset:ms] tag shifts every line (positive means earlier).
+func ParseLRC(data []byte) []LyricLine {
+	var lines []LyricLine
+	var offset time.Duration
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" {
+			continue
+		}
+
+		if m := reLrcOffset.FindStringSubmatch(line); len(m) == 2 {
+			ms, _ := strconv.Atoi(m[1])
+			offset = time.Duration(ms) * time.Millisecond
+			continue
+		}
+
+		var stamps []time.Duration
+		rest := line
+		for {
+			loc := reLrcTimestamp.FindStringSubmatchIndex(rest)
+			if loc == nil || loc[0] != 0 {
+				break
+			}
+			min, _ := strconv.Atoi(rest[loc[2]:loc[3]])
+			sec, _ := strconv.Atoi(rest[loc[4]:loc[5]])
+			var frac time.Duration
+			if loc[6] >= 0 {
+				digits := rest[loc[6]:loc[7]]
+				n, _ := strconv.Atoi(digits)
+				switch len(digits) {
+				case 1:
+					frac = time.Duration(n) * 100 * time.Millisecond
+				case 2:
+					frac = time.Duration(n) * 10 * time.Millisecond
+				default:
+					frac = time.Duration(n) * time.Millisecond
+				}
+			}
+			stamps = append(stamps, time.Duration(min)*time.Minute+time.Duration(sec)*time.Second+frac)
+			rest = rest[loc[1]:]
+		}
+
+		text := strings.TrimSpace(rest)
+		for _, ts := range stamps {
+			lines = append(lines, LyricLine{Time: ts, Text: text})
+		}
+	}
+
+	for i := range lines {
+		lines[i].Time -= offset
+		if lines[i].Time < 0 {
+			lines[i].Time = 0
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Time < lines[j].Time
+	})
+	return lines
+}
+
+// LyricTracker follows playback of the current track and publishes a
+// media_lyric event whenever the interpolated position crosses a line.
+type LyricTracker struct {
+	dir string
+
+	mu        sync.Mutex
+	trackKey  string
+	lyrics    *Lyrics
+	appName   string
+	pid       int
+	playing   bool
+	anchorPos time.Duration
+	anchorAt  time.Time
+	index     int
+	timer     *time.Timer
+	bus       core.Bus
+}
+
+func NewLyricTracker(dir string) *LyricTracker {
+	return &LyricTracker{
+		dir:   core.ExpandPath(dir),
+		index: -1,
+	}
+}
+
+// Update feeds the tracker the latest playback state. position is in
+// microseconds, as reported by MPRIS.
+func (t *LyricTracker) Update(bus core.Bus, appName string, pid int, trackKey, trackUrl, artist, title, status string, position int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.bus = bus
+	t.appName = appName
+	t.pid = pid
+
+	if trackKey != t.trackKey {
+		t.trackKey = trackKey
+		t.lyrics = t.find(trackUrl, artist, title)
+		t.index = -1
+	}
+
+	t.playing = status == "Playing"
+	t.anchorPos = time.Duration(position) * time.Microsecond
+	t.anchorAt = time.Now()
+	t.reschedule()
+}
+
+// SeekTo re-anchors the position after an MPRIS Seeked signal.
+func (t *LyricTracker) SeekTo(position int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.anchorPos = time.Duration(position) * time.Microsecond
+	t.anchorAt = time.Now()
+	t.reschedule()
+}
+
+// Reset forgets the current track.
+func (t *LyricTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	t.trackKey = ""
+	t.lyrics = nil
+	t.index = -1
+	t.playing = false
+}
+
+// Current returns the lyrics of the current track, or nil.
+func (t *LyricTracker) Current() *Lyrics {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lyrics
+}
+
+// reschedule emits the line at the current position if it changed and arms a
+// timer for the next one. Caller must hold t.mu.
+func (t *LyricTracker) reschedule() {
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	if t.lyrics == nil || len(t.lyrics.Lines) == 0 {
+		return
+	}
+
+	pos := t.anchorPos
+	if t.playing {
+		pos += time.Since(t.anchorAt)
+	}
+
+	lines := t.lyrics.Lines
+	idx := sort.Search(len(lines), func(i int) bool { return lines[i].Time > pos }) - 1
+	if idx != t.index {
+		t.index = idx
+		t.publish(idx)
+	}
+
+	if !t.playing || idx+1 >= len(lines) {
+		return
+	}
+	t.timer = time.AfterFunc(lines[idx+1].Time-pos, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.reschedule()
+	})
+}
+
+// publish emits the lyric line at idx. Caller must hold t.mu.
+func (t *LyricTracker) publish(idx int) {
+	if t.bus == nil {
+		return
+	}
+
+	lines := t.lyrics.Lines
+	text := ""
+	var ts time.Duration
+	if idx >= 0 {
+		text = lines[idx].Text
+		ts = lines[idx].Time
+	}
+	next := ""
+	if idx+1 < len(lines) {
+		next = lines[idx+1].Text
+	}
+
+	event := core.NewEvent(core.EventMediaLyric, t.appName, t.pid)
+	event.Metadata["text"] = text
+	event.Metadata["next"] = next
+	event.Metadata["index"] = idx
+	event.Metadata["time"] = ts.Milliseconds()
+	t.bus.Publish(event)
+}
+
+// find looks for an .lrc next to a local track first, then in the lyrics
+// directory as "<artist> - <title>.lrc" or "<artist>/<title>.lrc".
+func (t *LyricTracker) find(trackUrl, artist, title string) *Lyrics {
+	var candidates []string
+
+	if path := localFilePath(trackUrl); path != "" {
+		candidates = append(candidates, strings.TrimSuffix(path, filepath.Ext(path))+".lrc")
+	}
+
+	if t.dir != "" && title != "" {
+		if artist != "" {
+			candidates = append(candidates,
+				filepath.Join(t.dir, sanitizeFileName(artist+" - "+title)+".lrc"),
+				filepath.Join(t.dir, sanitizeFileName(artist), sanitizeFileName(title)+".lrc"))
+		}
+		candidates = append(candidates, filepath.Join(t.dir, sanitizeFileName(title)+".lrc"))
+	}
+
+	for _, path := range candidates {
+		if l := loadLyrics(path); l != nil {
+			return l
+		}
+	}
+
+	if t.dir != "" && artist != "" && title != "" {
+		if path := t.findCaseInsensitive(artist + " - " + title + ".lrc"); path != "" {
+			return loadLyrics(path)
+		}
+	}
+	return nil
+}
+
+func (t *LyricTracker) findCaseInsensitive(name string) string {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return ""
+	}
+	want := strings.ToLower(sanitizeFileName(name))
+	for _, e := range entries {
+		if !e.IsDir() && strings.ToLower(e.Name()) == want {
+			return filepath.Join(t.dir, e.Name())
+		}
+	}
+	return ""
+}
+
+func loadLyrics(path string) *Lyrics {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() > maxLyricsFileSize {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return &Lyrics{
+		Path:  path,
+		Raw:   string(data),
+		Lines: ParseLRC(data),
+	}
+}
+
+func sanitizeFileName(name string) string {
+	return strings.NewReplacer("/", "_", "\x00", "").Replace(strings.TrimSpace(name))
+}
@@ -0,0 +1,72 @@
+package media
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLRC(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name string
+		data string
+		want []LyricLine
+	}{
+		{
+			name: "several timestamps on a line",
+			data: "[00:12.00][01:02.50]Chorus\n[00:30.00]Verse",
+			want: []LyricLine{
+				{12 * time.Second, "Chorus"},
+				{30 * time.Second, "Verse"},
+				{62500 * ms, "Chorus"},
+			},
+		},
+		{
+			name: "fraction digits",
+			data: "[00:01.5]one\n[00:02.05]two\n[00:03.005]three\n[00:04:25]colon\n[00:05]none",
+			want: []LyricLine{
+				{1500 * ms, "one"},
+				{2050 * ms, "two"},
+				{3005 * ms, "three"},
+				{4250 * ms, "colon"},
+				{5 * time.Second, "none"},
+			},
+		},
+		{
+			name: "positive offset shows lines earlier and clamps to zero",
+			data: "[offset:+500]\n[00:01.00]a\n[00:00.20]b",
+			want: []LyricLine{
+				{0, "b"},
+				{500 * ms, "a"},
+			},
+		},
+		{
+			name: "negative offset",
+			data: "[Offset: -250]\n[00:01.00]a",
+			want: []LyricLine{
+				{1250 * ms, "a"},
+			},
+		},
+		{
+			name: "byte order mark and tags",
+			data: "\ufeff[ar:Artist]\n[ti:Title]\n\n[00:03.00] first \n[00:04.00]",
+			want: []LyricLine{
+				{3 * time.Second, "first"},
+				{4 * time.Second, ""},
+			},
+		},
+		{
+			name: "byte order mark before a timestamp",
+			data: "\ufeff[00:03.00]first",
+			want: []LyricLine{
+				{3 * time.Second, "first"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := ParseLRC([]byte(tt.data)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParseLRC = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
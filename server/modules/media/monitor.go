@@ -3,10 +3,9 @@ package media
 import (
 	"crypto/md5"
 	"dynamic-island-server/core"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -21,8 +20,16 @@ const (
 	propsInterface       = "org.freedesktop.DBus.Properties"
 	dbusInterface        = "org.freedesktop.DBus"
 	batchUpdateDelay     = 50 * time.Millisecond
+	maxFailedArt         = 256
 )
 
+// artInfo is everything derived from the current track's art URL.
+type artInfo struct {
+	path    string
+	thumbs  map[string]string
+	palette *Palette
+}
+
 type MediaSource struct {
 	conn          *dbus.Conn
 	stopChan      chan struct{}
@@ -30,25 +37,48 @@ type MediaSource struct {
 	stopOnce      sync.Once
 	mu            sync.Mutex
 	playerList    []string
+	players       map[string]*playerInfo
+	allowRules    []core.PlayerRule
+	ignoreRules   []core.PlayerRule
 	currentPlayer string
 	pendingUpdate *time.Timer
 
 	currentStatus   string
 	currentMetadata map[string]dbus.Variant
-	currentArtPath  string
-	artCache        map[string]string
-	httpClient      *http.Client
+	currentArt      artInfo
+	hasTrackList    bool
+	trackList       []TrackEntry
+	artCache        *ArtCache
+	thumbnailer     *Thumbnailer
+	// failedArt holds art URLs that could not be resolved or rendered.
+	failedArt map[[md5.Size]byte]bool
+	lyrics    *LyricTracker
+	history   *ListeningHistory
 }
 
-func NewMediaSource() *MediaSource {
-	return &MediaSource{
+func NewMediaSource(cfg core.MediaConfig) *MediaSource {
+	artDir := defaultArtCacheDir()
+	thumbDir := ""
+	if artDir != "" {
+		thumbDir = filepath.Join(artDir, thumbnailDirName)
+	}
+
+	s := &MediaSource{
 		stopChan:        make(chan struct{}),
 		eventChan:       make(chan *dbus.Signal, 10),
 		playerList:      make([]string, 0),
+		players:         make(map[string]*playerInfo),
+		allowRules:      cfg.AllowPlayers,
+		ignoreRules:     cfg.IgnorePlayers,
 		currentMetadata: make(map[string]dbus.Variant),
-		artCache:        make(map[string]string),
-		httpClient:      &http.Client{Timeout: 10 * time.Second},
-	}
+		artCache:        NewArtCache(artDir, defaultArtCacheQuota),
+		thumbnailer:     NewThumbnailer(thumbDir, defaultThumbnailQuota),
+		failedArt:       make(map[[md5.Size]byte]bool),
+		lyrics:          NewLyricTracker(cfg.LyricsDir),
+		history:         NewListeningHistory(core.DataDir(), cfg.RecordHistory),
+	}
+	s.artCache.OnEvict(s.thumbnailer.Remove)
+	return s
 }
 
 func (s *MediaSource) GetName() string {
@@ -76,6 +106,20 @@ func (s *MediaSource) Start(bus core.Bus, stopChan <-chan struct{}) error {
 		return fmt.Errorf("failed to add properties match: %v", call.Err)
 	}
 
+	seekedMatchRule := fmt.Sprintf("type='signal',interface='%s',member='Seeked'", mprisPlayerInterface)
+	call = s.conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, seekedMatchRule)
+	if call.Err != nil {
+		s.conn.Close()
+		return fmt.Errorf("failed to add seeked match: %v", call.Err)
+	}
+
+	trackListMatchRule := fmt.Sprintf("type='signal',interface='%s'", mprisTrackListInterface)
+	call = s.conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, trackListMatchRule)
+	if call.Err != nil {
+		s.conn.Close()
+		return fmt.Errorf("failed to add tracklist match: %v", call.Err)
+	}
+
 	s.conn.Signal(s.eventChan)
 	// log.Println("🎵 Media Monitor started (MPRIS)")
 
@@ -115,23 +159,42 @@ func (s *MediaSource) handleSignal(signal *dbus.Signal, bus core.Bus) {
 			return
 		}
 
-		if strings.HasPrefix(name, "org.mpris.MediaPlayer2.") && name != "org.mpris.MediaPlayer2" {
-			if s.isInvalidPlayer(name) {
-				return
-			}
-
+		if isMprisPlayerName(name) {
 			oldOwner, _ := signal.Body[1].(string)
 			newOwner, _ := signal.Body[2].(string)
 
 			if oldOwner == "" && newOwner != "" {
 
 				// log.Printf("🎵 MPRIS player appeared: %s", name)
+				info := s.probePlayer(name, newOwner)
+				s.mu.Lock()
+				s.players[name] = info
+				eligible := s.isEligiblePlayer(info)
+				s.mu.Unlock()
+				if !eligible {
+					return
+				}
+
 				s.disconnectPlayer()
 				s.addToPlayerList(name)
 				s.connectToPlayer(name, bus)
 			} else if oldOwner != "" && newOwner == "" {
 
 				// log.Printf("🎵 MPRIS player disappeared: %s", name)
+				s.mu.Lock()
+				delete(s.players, name)
+				listed := false
+				for _, p := range s.playerList {
+					if p == name {
+						listed = true
+						break
+					}
+				}
+				s.mu.Unlock()
+				if !listed {
+					return
+				}
+
 				s.removeFromPlayerList(name)
 
 				s.disconnectPlayer()
@@ -161,16 +224,13 @@ func (s *MediaSource) handleSignal(signal *dbus.Signal, bus core.Bus) {
 			return
 		}
 
-		playerName := signal.Sender
-		if playerName == "" || !strings.HasPrefix(playerName, "org.mpris.MediaPlayer2.") {
-
-			s.mu.Lock()
-			playerName = s.currentPlayer
-			s.mu.Unlock()
+		playerName := s.signalPlayer(signal)
+		if playerName == "" {
+			return
+		}
 
-			if playerName == "" {
-				return
-			}
+		if s.notePlayerMetadata(playerName, changedProps, bus) {
+			return
 		}
 
 		s.mu.Lock()
@@ -182,18 +242,94 @@ func (s *MediaSource) handleSignal(signal *dbus.Signal, bus core.Bus) {
 		}
 
 		s.handlePropertiesChanged(changedProps, bus)
+		return
+	}
+
+	if signal.Name == mprisPlayerInterface+".Seeked" && len(signal.Body) >= 1 {
+		if signal.Path != dbus.ObjectPath(mprisPath) {
+			return
+		}
+
+		position, ok := signal.Body[0].(int64)
+		if !ok {
+			return
+		}
+
+		if playerName := s.signalPlayer(signal); playerName == "" || playerName != s.GetCurrentPlayer() {
+			return
+		}
+
+		s.lyrics.SeekTo(position)
+		return
+	}
+
+	if strings.HasPrefix(signal.Name, mprisTrackListInterface+".") {
+		if signal.Path != dbus.ObjectPath(mprisPath) {
+			return
+		}
+		if playerName := s.signalPlayer(signal); playerName == "" || playerName != s.GetCurrentPlayer() {
+			return
+		}
+
+		s.handleTrackListSignal(signal, bus)
 	}
 }
 
-func (s *MediaSource) isInvalidPlayer(name string) bool {
-	nameLower := strings.ToLower(name)
-	invalidPatterns := []string{"bluetooth", "mouse", "keyboard", "input", "device"}
-	for _, pattern := range invalidPatterns {
-		if strings.Contains(nameLower, pattern) {
-			return true
+// signalPlayer maps the sender of a player signal to its well-known name,
+// falling back to the current player when the sender is unknown.
+func (s *MediaSource) signalPlayer(signal *dbus.Signal) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if info := s.playerByOwner(signal.Sender); info != nil {
+		return info.name
+	}
+	if isMprisPlayerName(signal.Sender) {
+		return signal.Sender
+	}
+	return s.currentPlayer
+}
+
+// notePlayerMetadata remembers that playerName exposed a track. A player
+// that becomes eligible this way takes over like a newly appeared player, so
+// rules on metadata only delay players until their first track; it returns
+// true if that happened.
+func (s *MediaSource) notePlayerMetadata(playerName string, changedProps map[string]dbus.Variant, bus core.Bus) bool {
+	metadataVar, ok := changedProps["Metadata"]
+	if !ok {
+		return false
+	}
+	metadata, ok := metadataVar.Value().(map[string]dbus.Variant)
+	if !ok || !s.metadataHasTrack(metadata) {
+		return false
+	}
+
+	s.mu.Lock()
+	info, known := s.players[playerName]
+	if !known || info.hasMetadata {
+		s.mu.Unlock()
+		return false
+	}
+	info.hasMetadata = true
+
+	listed := false
+	for _, p := range s.playerList {
+		if p == playerName {
+			listed = true
+			break
 		}
 	}
-	return false
+	eligible := !listed && s.isEligiblePlayer(info)
+	s.mu.Unlock()
+
+	if !eligible {
+		return false
+	}
+
+	s.disconnectPlayer()
+	s.addToPlayerList(playerName)
+	s.connectToPlayer(playerName, bus)
+	return true
 }
 
 func (s *MediaSource) scanAndUpdatePlayers(bus core.Bus) {
@@ -204,21 +340,26 @@ func (s *MediaSource) scanAndUpdatePlayers(bus core.Bus) {
 		return
 	}
 
-	var validPlayers []string
+	var infos []*playerInfo
 	for _, name := range names {
-		if strings.HasPrefix(name, "org.mpris.MediaPlayer2.") && name != "org.mpris.MediaPlayer2" {
-			if !s.isInvalidPlayer(name) {
-				validPlayers = append(validPlayers, name)
-			}
+		if isMprisPlayerName(name) {
+			infos = append(infos, s.probePlayer(name, ""))
 		}
 	}
 
-	// log.Printf("🎵 Found %d media player(s)", len(validPlayers))
-
 	s.mu.Lock()
-	s.playerList = validPlayers
+	s.playerList = make([]string, 0)
+	for _, info := range infos {
+		s.players[info.name] = info
+		if s.isEligiblePlayer(info) {
+			s.playerList = append(s.playerList, info.name)
+		}
+	}
+	validPlayers := s.playerList
 	s.mu.Unlock()
 
+	// log.Printf("🎵 Found %d media player(s)", len(validPlayers))
+
 	if len(validPlayers) > 0 {
 		s.connectToPlayer(validPlayers[0], bus)
 	}
@@ -255,13 +396,17 @@ func (s *MediaSource) disconnectPlayer() {
 	s.currentPlayer = ""
 	s.currentStatus = ""
 	s.currentMetadata = make(map[string]dbus.Variant)
-	s.currentArtPath = ""
+	s.currentArt = artInfo{}
+	s.hasTrackList = false
+	s.trackList = nil
 
 	if s.pendingUpdate != nil {
 		s.pendingUpdate.Stop()
 		s.pendingUpdate = nil
 	}
 	s.mu.Unlock()
+
+	s.lyrics.Reset()
 }
 
 func (s *MediaSource) connectToPlayer(playerName string, bus core.Bus) {
@@ -272,6 +417,10 @@ func (s *MediaSource) connectToPlayer(playerName string, bus core.Bus) {
 	// log.Printf("🎵 Connected to player: %s", playerName)
 
 	s.performInitialUpdate(bus, playerName)
+	s.loadTrackList(playerName)
+	if s.HasTrackList() {
+		s.publishTrackListChanged(bus)
+	}
 }
 
 func (s *MediaSource) GetCurrentPlayer() string {
@@ -283,7 +432,7 @@ func (s *MediaSource) GetCurrentPlayer() string {
 func (s *MediaSource) GetState() (string, string, map[string]dbus.Variant, string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.currentPlayer, s.currentStatus, s.currentMetadata, s.currentArtPath
+	return s.currentPlayer, s.currentStatus, s.currentMetadata, s.currentArt.path
 }
 
 func (s *MediaSource) performInitialUpdate(bus core.Bus, playerName string) {
@@ -337,6 +486,15 @@ func (s *MediaSource) handlePropertiesChanged(changedProps map[string]dbus.Varia
 }
 
 func (s *MediaSource) batchUpdate(bus core.Bus, updates map[string]interface{}) {
+	// Cached art is looked up on disk before taking the lock
+	metadata, hasMetadata := updates["metadata"].(map[string]dbus.Variant)
+	var artUrl string
+	var art artInfo
+	if hasMetadata {
+		artUrl = s.ExtractArtUrl(metadata)
+		art = s.lookupArt(artUrl)
+	}
+
 	s.mu.Lock()
 
 	if s.pendingUpdate != nil {
@@ -344,26 +502,11 @@ func (s *MediaSource) batchUpdate(bus core.Bus, updates map[string]interface{})
 		s.pendingUpdate = nil
 	}
 
-	if metadata, ok := updates["metadata"].(map[string]dbus.Variant); ok {
+	if hasMetadata {
 		s.currentMetadata = metadata
-
-		artUrl := s.ExtractArtUrl(metadata)
-		if artUrl != "" {
-			if strings.HasPrefix(artUrl, "http://") || strings.HasPrefix(artUrl, "https://") {
-
-				if cachedPath, ok := s.artCache[artUrl]; ok {
-					s.currentArtPath = cachedPath
-				} else {
-
-					s.currentArtPath = ""
-					go s.downloadAndCacheImage(artUrl, bus)
-				}
-			} else {
-
-				s.currentArtPath = artUrl
-			}
-		} else {
-			s.currentArtPath = ""
+		s.currentArt = art
+		if artUrl != "" && (art.thumbs == nil || art.palette == nil) && !s.failedArt[artKey(artUrl)] {
+			go s.processArt(artUrl, bus)
 		}
 	}
 
@@ -376,11 +519,11 @@ func (s *MediaSource) batchUpdate(bus core.Bus, updates map[string]interface{})
 		s.pendingUpdate = nil
 		status := s.currentStatus
 		metadata := s.currentMetadata
-		artPath := s.currentArtPath
+		art := s.currentArt
 		playerName := s.currentPlayer
 		s.mu.Unlock()
 
-		s.notifyCallbacks(bus, playerName, status, metadata, artPath)
+		s.notifyCallbacks(bus, playerName, status, metadata, art)
 	})
 	s.mu.Unlock()
 }
@@ -453,7 +596,7 @@ func (s *MediaSource) ExtractArtist(metadata map[string]dbus.Variant) string {
 	return ""
 }
 
-func (s *MediaSource) notifyCallbacks(bus core.Bus, playerName string, status string, metadata map[string]dbus.Variant, artPath string) {
+func (s *MediaSource) notifyCallbacks(bus core.Bus, playerName string, status string, metadata map[string]dbus.Variant, art artInfo) {
 	if playerName == "" {
 
 		event := core.NewEvent(core.EventMediaChanged, "", 0)
@@ -462,16 +605,37 @@ func (s *MediaSource) notifyCallbacks(bus core.Bus, playerName string, status st
 		event.Metadata["artist"] = ""
 		event.Metadata["album"] = ""
 		event.Metadata["artUrl"] = ""
+		event.Metadata["thumbnails"] = map[string]string{}
+		event.Metadata["palette"] = map[string]string{}
 		event.Metadata["player"] = ""
+		event.Metadata["playerIdentity"] = ""
+		event.Metadata["playerIcon"] = ""
+		event.Metadata["desktopEntry"] = ""
+		event.Metadata["playerSite"] = ""
 		event.Metadata["position"] = int64(0)
 		event.Metadata["length"] = int64(0)
 		bus.Publish(event)
+		s.lyrics.Reset()
+		s.history.Finish()
 		return
 	}
 
-	appName := strings.TrimPrefix(playerName, "org.mpris.MediaPlayer2.")
-	if appName == "" {
-		appName = "unknown"
+	s.mu.Lock()
+	var info *playerInfo
+	if p, ok := s.players[playerName]; ok {
+		copied := *p
+		info = &copied
+	}
+	s.mu.Unlock()
+
+	appName := playerDisplayName(info, playerName)
+	desktopEntry := ""
+	if info != nil {
+		desktopEntry = info.desktopEntry
+	}
+	playerIcon := desktopEntryIcon(desktopEntry)
+	if playerIcon == "" {
+		playerIcon = desktopEntry
 	}
 
 	pid := s.getPlayerPID(playerName)
@@ -510,7 +674,7 @@ func (s *MediaSource) notifyCallbacks(bus core.Bus, playerName string, status st
 		}
 	}
 
-	artUrl := artPath
+	artUrl := art.path
 
 	isPlaying := status == "Playing"
 
@@ -521,12 +685,79 @@ func (s *MediaSource) notifyCallbacks(bus core.Bus, playerName string, status st
 	event.Metadata["artist"] = artist
 	event.Metadata["album"] = album
 	event.Metadata["artUrl"] = artUrl
+	thumbs := art.thumbs
+	if thumbs == nil {
+		thumbs = map[string]string{}
+	}
+	event.Metadata["thumbnails"] = thumbs
+	event.Metadata["palette"] = art.palette.toMap()
 	event.Metadata["player"] = playerName
+	event.Metadata["playerIdentity"] = appName
+	event.Metadata["playerIcon"] = playerIcon
+	event.Metadata["desktopEntry"] = desktopEntry
+	event.Metadata["playerSite"] = ""
+	if isBrowserPlayer(info, playerName) {
+		event.Metadata["playerSite"] = siteFromUrl(s.ExtractMetadataValue(metadata, []string{"xesam:url"}))
+	}
 	event.Metadata["position"] = position
 	event.Metadata["length"] = length
 
 	// log.Printf("🎵 Media: [%s] %s - %s (%s) PID: %d", appName, artist, title, status, pid)
 	bus.Publish(event)
+
+	trackKey := s.trackKey(playerName, metadata)
+	trackUrl := s.ExtractMetadataValue(metadata, []string{"xesam:url"})
+	s.lyrics.Update(bus, appName, pid, trackKey, trackUrl, artist, title, status, position)
+
+	play := Play{
+		Player:        appName,
+		Title:         title,
+		Artist:        artist,
+		Album:         album,
+		MusicBrainzID: s.ExtractMetadataValue(metadata, []string{"xesam:musicBrainzTrackID"}),
+	}
+	if metadata != nil {
+		if v, ok := metadata["xesam:trackNumber"]; ok {
+			if n, ok := v.Value().(int32); ok {
+				play.TrackNumber = int(n)
+			}
+		}
+	}
+	s.history.Observe(trackKey, play, time.Duration(length)*time.Microsecond, status)
+}
+
+// trackKey identifies the current track across metadata refreshes.
+func (s *MediaSource) trackKey(playerName string, metadata map[string]dbus.Variant) string {
+	trackId := ""
+	if metadata != nil {
+		if v, ok := metadata["mpris:trackid"]; ok {
+			if id, ok := v.Value().(dbus.ObjectPath); ok {
+				trackId = string(id)
+			} else if id, ok := v.Value().(string); ok {
+				trackId = id
+			}
+		}
+	}
+	return strings.Join([]string{playerName, trackId, s.ExtractTitle(metadata), s.ExtractArtist(metadata)}, "\x00")
+}
+
+// GetRecentTracks returns up to n recorded plays, newest first.
+func (s *MediaSource) GetRecentTracks(n int) []Play {
+	return s.history.Recent(n)
+}
+
+// ExportScrobblerLog writes the listening history as a .scrobbler.log file
+// in the exports directory under the data directory.
+func (s *MediaSource) ExportScrobblerLog(name string) (string, int, error) {
+	return s.history.ExportScrobblerLog(name)
+}
+
+// GetLyrics returns the raw .lrc content for the current track, or "".
+func (s *MediaSource) GetLyrics() string {
+	if l := s.lyrics.Current(); l != nil {
+		return l.Raw
+	}
+	return ""
 }
 
 func (s *MediaSource) getPlayerPID(serviceName string) int {
@@ -548,63 +779,91 @@ func (s *MediaSource) getPlayerPID(serviceName string) int {
 	return int(pid)
 }
 
-func (s *MediaSource) downloadAndCacheImage(url string, bus core.Bus) {
-	resp, err := s.httpClient.Get(url)
-	if err != nil {
-		// log.Printf("⚠️ MediaSource: Error downloading album art from %s: %v", url, err)
-		return
+// lookupArt returns the art of artUrl that is already on disk without
+// downloading or rendering anything.
+func (s *MediaSource) lookupArt(artUrl string) artInfo {
+	var art artInfo
+	if artUrl == "" {
+		return art
+	}
+	if path, ok := s.artCache.LookupResolved(artUrl); ok {
+		art.path = path
+		if thumbs, ok := s.thumbnailer.Lookup(path); ok {
+			art.thumbs = thumbs
+		}
+		if palette, ok := s.thumbnailer.LookupPalette(path); ok {
+			art.palette = palette
+		}
 	}
-	defer resp.Body.Close()
+	return art
+}
 
-	if resp.StatusCode != http.StatusOK {
-		// log.Printf("⚠️ MediaSource: Failed to download album art from %s: status %d", url, resp.StatusCode)
-		return
-	}
+// artKey keys failedArt without keeping large data: URLs around.
+func artKey(artUrl string) [md5.Size]byte {
+	return md5.Sum([]byte(artUrl))
+}
 
-	data, err := io.ReadAll(resp.Body)
+// processArt resolves artUrl to a local file, renders its thumbnails and
+// palette, and republishes the current track once they are ready. Art that
+// fails is not tried again, so a broken cover is not decoded on every
+// metadata update.
+func (s *MediaSource) processArt(artUrl string, bus core.Bus) {
+	path, err := s.artCache.Resolve(artUrl)
 	if err != nil {
-		// log.Printf("⚠️ MediaSource: Error reading album art bytes from %s: %v", url, err)
+		// log.Printf("⚠️ MediaSource: Error resolving album art %s: %v", artUrl, err)
+		s.markArtFailed(artUrl)
 		return
 	}
 
-	path := s.saveAndCacheImage(url, data)
-	if path != "" {
-
-		s.mu.Lock()
-		s.artCache[url] = path
-		s.currentArtPath = path
-		status := s.currentStatus
-		metadata := s.currentMetadata
-		playerName := s.currentPlayer
-		s.mu.Unlock()
-
-		s.notifyCallbacks(bus, playerName, status, metadata, path)
+	thumbs, err := s.thumbnailer.Generate(path)
+	if errors.Is(err, fs.ErrNotExist) && s.artCache.Invalidate(artUrl) {
+		// The cached file was deleted behind the cache's back; fetch it again
+		if path, err = s.artCache.Resolve(artUrl); err == nil {
+			thumbs, err = s.thumbnailer.Generate(path)
+		}
 	}
-}
 
-func (s *MediaSource) saveAndCacheImage(url string, data []byte) string {
-	cacheDir, err := os.UserCacheDir()
-	if err != nil {
-		// log.Printf("⚠️ MediaSource: Error getting cache directory: %v", err)
-		return ""
+	art := artInfo{path: path}
+	if err == nil {
+		art.thumbs = thumbs
+		if palette, err := s.thumbnailer.GeneratePalette(path, thumbs); err == nil {
+			art.palette = palette
+		} else {
+			// log.Printf("⚠️ MediaSource: Error extracting palette for %s: %v", path, err)
+			s.markArtFailed(artUrl)
+		}
+	} else {
+		// log.Printf("⚠️ MediaSource: Error creating thumbnails for %s: %v", path, err)
+		s.markArtFailed(artUrl)
 	}
 
-	dir := filepath.Join(cacheDir, "dynamic-island-art")
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		// log.Printf("⚠️ MediaSource: Error creating cache directory: %v", err)
-		return ""
+	s.mu.Lock()
+	if s.ExtractArtUrl(s.currentMetadata) != artUrl {
+		// Track changed while processing
+		s.mu.Unlock()
+		return
 	}
+	if s.currentArt.path == path && art.thumbs == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.currentArt = art
+	status := s.currentStatus
+	metadata := s.currentMetadata
+	playerName := s.currentPlayer
+	s.mu.Unlock()
 
-	hash := md5.Sum([]byte(url))
-	filename := fmt.Sprintf("%x.jpg", hash)
-	path := filepath.Join(dir, filename)
+	s.notifyCallbacks(bus, playerName, status, metadata, art)
+}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		// log.Printf("⚠️ MediaSource: Error saving album art to cache: %v", err)
-		return ""
-	}
+func (s *MediaSource) markArtFailed(artUrl string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	return path
+	if len(s.failedArt) >= maxFailedArt {
+		s.failedArt = make(map[[md5.Size]byte]bool)
+	}
+	s.failedArt[artKey(artUrl)] = true
 }
 
 func (s *MediaSource) Stop() {
@@ -615,6 +874,8 @@ func (s *MediaSource) Stop() {
 			s.pendingUpdate = nil
 		}
 		s.mu.Unlock()
+		s.artCache.Close()
+		s.history.Finish()
 		close(s.stopChan)
 	})
 }
@@ -0,0 +1,238 @@
+package media
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Palette holds the colours the island derives from the current cover, as
+// "#rrggbb" strings.
+type Palette struct {
+	Dominant   string `json:"dominant"`
+	Vibrant    string `json:"vibrant"`
+	Muted      string `json:"muted"`
+	Foreground string `json:"foreground"`
+}
+
+func (p *Palette) toMap() map[string]string {
+	if p == nil {
+		return map[string]string{}
+	}
+	return map[string]string{
+		"dominant":   p.Dominant,
+		"vibrant":    p.Vibrant,
+		"muted":      p.Muted,
+		"foreground": p.Foreground,
+	}
+}
+
+type colorBucket struct {
+	r, g, b float64
+	count   int
+}
+
+func (c *colorBucket) rgb() (float64, float64, float64) {
+	n := float64(c.count)
+	return c.r / n, c.g / n, c.b / n
+}
+
+func (t *Thumbnailer) palettePath(src string) string {
+	return filepath.Join(t.dir, fmt.Sprintf("%x-palette.json", md5.Sum([]byte(src))))
+}
+
+// LookupPalette returns the cached palette for src if it is up to date.
+func (t *Thumbnailer) LookupPalette(src string) (*Palette, bool) {
+	if t.dir == "" || src == "" {
+		return nil, false
+	}
+
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return nil, false
+	}
+	path := t.palettePath(src)
+	info, err := os.Stat(path)
+	if err != nil || info.ModTime().Before(srcInfo.ModTime()) {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var p Palette
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, false
+	}
+	return &p, true
+}
+
+// GeneratePalette computes the palette for src from its rendered thumbnail
+// and caches it next to the thumbnails.
+func (t *Thumbnailer) GeneratePalette(src string, thumbs map[string]string) (*Palette, error) {
+	if p, ok := t.LookupPalette(src); ok {
+		return p, nil
+	}
+
+	thumbPath := thumbs["medium"]
+	if thumbPath == "" {
+		return nil, fmt.Errorf("no thumbnail to sample")
+	}
+
+	f, err := os.Open(thumbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open thumbnail: %v", err)
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode thumbnail: %v", err)
+	}
+
+	p := extractPalette(img)
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode palette: %v", err)
+	}
+	if err := os.WriteFile(t.palettePath(src), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to save palette: %v", err)
+	}
+	return p, nil
+}
+
+// extractPalette buckets pixels into a 4 bit per channel histogram and picks
+// the most common, most saturated and least saturated colours.
+func extractPalette(img image.Image) *Palette {
+	buckets := make(map[uint16]*colorBucket)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r16, g16, b16, a16 := img.At(x, y).RGBA()
+			if a16 < 0x8000 {
+				continue
+			}
+			r, g, bl := r16>>8, g16>>8, b16>>8
+			key := uint16(r>>4)<<8 | uint16(g>>4)<<4 | uint16(bl>>4)
+			bucket, ok := buckets[key]
+			if !ok {
+				bucket = &colorBucket{}
+				buckets[key] = bucket
+			}
+			bucket.r += float64(r)
+			bucket.g += float64(g)
+			bucket.b += float64(bl)
+			bucket.count++
+		}
+	}
+
+	if len(buckets) == 0 {
+		return &Palette{
+			Dominant:   "#808080",
+			Vibrant:    "#808080",
+			Muted:      "#808080",
+			Foreground: "#ffffff",
+		}
+	}
+
+	var dominant, vibrant, muted *colorBucket
+	var vibrantScore, mutedScore float64
+	for _, bucket := range buckets {
+		if dominant == nil || bucket.count > dominant.count {
+			dominant = bucket
+		}
+
+		_, sat, light := rgbToHSL(bucket.rgb())
+		weight := math.Sqrt(float64(bucket.count))
+
+		if light > 0.25 && light < 0.8 {
+			if score := sat * weight; sat > 0.35 && score > vibrantScore {
+				vibrant, vibrantScore = bucket, score
+			}
+		}
+		if light > 0.2 && light < 0.7 {
+			if score := (1 - sat) * weight; sat < 0.4 && score > mutedScore {
+				muted, mutedScore = bucket, score
+			}
+		}
+	}
+	if vibrant == nil {
+		vibrant = dominant
+	}
+	if muted == nil {
+		muted = dominant
+	}
+
+	dr, dg, db := dominant.rgb()
+	return &Palette{
+		Dominant:   hexColor(dr, dg, db),
+		Vibrant:    hexColor(vibrant.rgb()),
+		Muted:      hexColor(muted.rgb()),
+		Foreground: foregroundFor(dr, dg, db),
+	}
+}
+
+// foregroundFor picks black or white, whichever has the higher WCAG contrast
+// ratio against the given background.
+func foregroundFor(r, g, b float64) string {
+	lum := relativeLuminance(r, g, b)
+	whiteContrast := 1.05 / (lum + 0.05)
+	blackContrast := (lum + 0.05) / 0.05
+	if whiteContrast >= blackContrast {
+		return "#ffffff"
+	}
+	return "#000000"
+}
+
+func relativeLuminance(r, g, b float64) float64 {
+	linear := func(c float64) float64 {
+		c /= 255
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
+
+func rgbToHSL(r, g, b float64) (h, s, l float64) {
+	r, g, b = r/255, g/255, b/255
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	l = (max + min) / 2
+	if max == min {
+		return 0, 0, l
+	}
+
+	d := max - min
+	if l > 0.5 {
+		s = d / (2 - max - min)
+	} else {
+		s = d / (max + min)
+	}
+
+	switch max {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h / 6, s, l
+}
+
+func hexColor(r, g, b float64) string {
+	clamp := func(v float64) int {
+		return int(math.Max(0, math.Min(255, math.Round(v))))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", clamp(r), clamp(g), clamp(b))
+}
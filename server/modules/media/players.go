@@ -0,0 +1,135 @@
+package media
+
+import (
+	"dynamic-island-server/core"
+	"path"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	mprisRootInterface = "org.mpris.MediaPlayer2"
+	mprisBusPrefix     = "org.mpris.MediaPlayer2."
+	playerctldBusName  = "org.mpris.MediaPlayer2.playerctld"
+)
+
+// playerInfo is what we know about an MPRIS bus name, whether or not it is
+// eligible to become the current player.
+type playerInfo struct {
+	name         string
+	owner        string
+	identity     string
+	desktopEntry string
+	hasMetadata  bool
+}
+
+func isMprisPlayerName(name string) bool {
+	return strings.HasPrefix(name, mprisBusPrefix)
+}
+
+// probePlayer reads the root interface and current metadata of a player.
+func (s *MediaSource) probePlayer(name, owner string) *playerInfo {
+	info := &playerInfo{name: name, owner: owner}
+
+	if info.owner == "" {
+		var unique string
+		if err := s.conn.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, name).Store(&unique); err == nil {
+			info.owner = unique
+		}
+	}
+
+	obj := s.conn.Object(name, dbus.ObjectPath(mprisPath))
+	if v, err := obj.GetProperty(mprisRootInterface + ".Identity"); err == nil {
+		info.identity, _ = v.Value().(string)
+	}
+	if v, err := obj.GetProperty(mprisRootInterface + ".DesktopEntry"); err == nil {
+		info.desktopEntry, _ = v.Value().(string)
+	}
+	if v, err := obj.GetProperty(mprisPlayerInterface + ".Metadata"); err == nil {
+		if metadata, ok := v.Value().(map[string]dbus.Variant); ok {
+			info.hasMetadata = s.metadataHasTrack(metadata)
+		}
+	}
+
+	return info
+}
+
+// metadataHasTrack reports whether metadata describes an actual track rather
+// than the empty or NoTrack placeholder some players expose while idle.
+func (s *MediaSource) metadataHasTrack(metadata map[string]dbus.Variant) bool {
+	return s.ExtractTitle(metadata) != "" || s.ExtractMetadataValue(metadata, []string{"xesam:url"}) != ""
+}
+
+// isEligiblePlayer applies playerctld/duplicate handling and the configured
+// allow and ignore rules. Caller must hold s.mu.
+func (s *MediaSource) isEligiblePlayer(info *playerInfo) bool {
+	// playerctld only proxies whichever player is active, which we already
+	// see directly.
+	if info.name == playerctldBusName {
+		return false
+	}
+
+	// Some players register several well-known names on one connection.
+	if info.owner != "" {
+		for _, name := range s.playerList {
+			other, ok := s.players[name]
+			if ok && other.name != info.name && other.owner == info.owner {
+				return false
+			}
+		}
+	}
+
+	for _, rule := range s.allowRules {
+		if matchPlayerRule(rule, info) {
+			return true
+		}
+	}
+	for _, rule := range s.ignoreRules {
+		if matchPlayerRule(rule, info) {
+			return false
+		}
+	}
+	return true
+}
+
+// playerByOwner maps a unique connection name to the player's well-known
+// name. Caller must hold s.mu.
+func (s *MediaSource) playerByOwner(owner string) *playerInfo {
+	if owner == "" {
+		return nil
+	}
+	for _, info := range s.players {
+		if info.owner == owner {
+			return info
+		}
+	}
+	return nil
+}
+
+// matchPlayerRule reports whether every field set in rule matches info.
+// String fields are case-insensitive shell globs.
+func matchPlayerRule(rule core.PlayerRule, info *playerInfo) bool {
+	if rule.BusName == "" && rule.Identity == "" && rule.DesktopEntry == "" && rule.HasMetadata == nil {
+		return false
+	}
+	if rule.BusName != "" && !matchGlob(rule.BusName, info.name) &&
+		!matchGlob(rule.BusName, strings.TrimPrefix(info.name, mprisBusPrefix)) {
+		return false
+	}
+	if rule.Identity != "" && !matchGlob(rule.Identity, info.identity) {
+		return false
+	}
+	if rule.DesktopEntry != "" && !matchGlob(rule.DesktopEntry, info.desktopEntry) {
+		return false
+	}
+	if rule.HasMetadata != nil && *rule.HasMetadata != info.hasMetadata {
+		return false
+	}
+	return true
+}
+
+func matchGlob(pattern, value string) bool {
+	ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && ok
+}
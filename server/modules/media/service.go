@@ -1,6 +1,8 @@
 package media
 
 import (
+	"dynamic-island-server/modules/audio"
+	"encoding/json"
 	"fmt"
 
 	"github.com/godbus/dbus/v5"
@@ -9,12 +11,14 @@ import (
 type MediaService struct {
 	conn        *dbus.Conn
 	mediaSource *MediaSource
+	audio       *audio.Backend
 }
 
-func NewMediaService(conn *dbus.Conn, mediaSource *MediaSource) *MediaService {
+func NewMediaService(conn *dbus.Conn, mediaSource *MediaSource, backend *audio.Backend) *MediaService {
 	return &MediaService{
 		conn:        conn,
 		mediaSource: mediaSource,
+		audio:       backend,
 	}
 }
 
@@ -105,3 +109,90 @@ func (s *MediaService) GetMediaInfo() (string, string, string, string, string, e
 
 	return player, status, title, artist, artUrl, nil
 }
+
+func (s *MediaService) GetLyrics() (string, error) {
+	if s.mediaSource == nil {
+		return "", fmt.Errorf("media source not available")
+	}
+	return s.mediaSource.GetLyrics(), nil
+}
+
+func (s *MediaService) GetRecentTracks(n int) (string, error) {
+	if s.mediaSource == nil {
+		return "", fmt.Errorf("media source not available")
+	}
+	data, err := json.Marshal(s.mediaSource.GetRecentTracks(n))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tracks: %v", err)
+	}
+	return string(data), nil
+}
+
+func (s *MediaService) ExportScrobblerLog(name string) (string, int, error) {
+	if s.mediaSource == nil {
+		return "", 0, fmt.Errorf("media source not available")
+	}
+	return s.mediaSource.ExportScrobblerLog(name)
+}
+
+func (s *MediaService) GetTrackList() (string, error) {
+	if s.mediaSource == nil {
+		return "", fmt.Errorf("media source not available")
+	}
+	data, err := json.Marshal(s.mediaSource.GetTrackList())
+	if err != nil {
+		return "", fmt.Errorf("failed to encode track list: %v", err)
+	}
+	return string(data), nil
+}
+
+func (s *MediaService) GoToTrack(id string) error {
+	playerName, err := s.getCurrentPlayer()
+	if err != nil {
+		return err
+	}
+	if !s.mediaSource.HasTrackList() {
+		return fmt.Errorf("player does not support track lists")
+	}
+	if !dbus.ObjectPath(id).IsValid() {
+		return fmt.Errorf("invalid track id: %s", id)
+	}
+
+	obj := s.conn.Object(playerName, dbus.ObjectPath(mprisPath))
+	if call := obj.Call(mprisTrackListInterface+".GoTo", 0, dbus.ObjectPath(id)); call.Err != nil {
+		return fmt.Errorf("failed to go to track: %v", call.Err)
+	}
+	return nil
+}
+
+func (s *MediaService) GetPlaylists() (string, error) {
+	playerName, err := s.getCurrentPlayer()
+	if err != nil {
+		return "", err
+	}
+	playlists, err := fetchPlaylists(s.conn, playerName)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(playlists)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode playlists: %v", err)
+	}
+	return string(data), nil
+}
+
+func (s *MediaService) ActivatePlaylist(id string) error {
+	playerName, err := s.getCurrentPlayer()
+	if err != nil {
+		return err
+	}
+	if !dbus.ObjectPath(id).IsValid() {
+		return fmt.Errorf("invalid playlist id: %s", id)
+	}
+
+	obj := s.conn.Object(playerName, dbus.ObjectPath(mprisPath))
+	if call := obj.Call(mprisPlaylistsInterface+".ActivatePlaylist", 0, dbus.ObjectPath(id)); call.Err != nil {
+		return fmt.Errorf("failed to activate playlist: %v", call.Err)
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package media
+
+import (
+	"crypto/md5"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	thumbnailDirName      = "thumbs"
+	defaultThumbnailQuota = 16 * 1024 * 1024
+	maxArtSourcePixels    = 40 * 1000 * 1000
+)
+
+// thumbnailSizes are the square edge lengths (in pixels) rendered for the
+// views: compact pill (24px), expanded header (56px) and expanded art (120px),
+// each at 2x for HiDPI.
+var thumbnailSizes = map[string]int{
+	"small":  48,
+	"medium": 112,
+	"large":  240,
+}
+
+type thumbnailJob struct {
+	done   chan struct{}
+	thumbs map[string]string
+	err    error
+}
+
+// Thumbnailer renders square PNG thumbnails of album art so the shell never
+// has to decode and scale full size covers itself.
+//
+// Thumbnails of cached art are removed with it, but local art files are not
+// cached, so the directory is also kept under its own quota.
+type Thumbnailer struct {
+	dir      string
+	quota    int64
+	mu       sync.Mutex
+	inflight map[string]*thumbnailJob
+	pruneMu  sync.Mutex
+}
+
+func NewThumbnailer(dir string, quota int64) *Thumbnailer {
+	if quota <= 0 {
+		quota = defaultThumbnailQuota
+	}
+	return &Thumbnailer{
+		dir:      dir,
+		quota:    quota,
+		inflight: make(map[string]*thumbnailJob),
+	}
+}
+
+// Lookup returns existing thumbnails for src if they are at least as new as src.
+func (t *Thumbnailer) Lookup(src string) (map[string]string, bool) {
+	if t.dir == "" || src == "" {
+		return nil, false
+	}
+
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return nil, false
+	}
+
+	thumbs := make(map[string]string, len(thumbnailSizes))
+	for name, size := range thumbnailSizes {
+		path := t.thumbnailPath(src, size)
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Before(srcInfo.ModTime()) {
+			return nil, false
+		}
+		thumbs[name] = path
+	}
+	return thumbs, true
+}
+
+// Generate renders thumbnails for src. Concurrent calls for the same src
+// share a single job.
+func (t *Thumbnailer) Generate(src string) (map[string]string, error) {
+	if thumbs, ok := t.Lookup(src); ok {
+		return thumbs, nil
+	}
+
+	t.mu.Lock()
+	if job, ok := t.inflight[src]; ok {
+		t.mu.Unlock()
+		<-job.done
+		return job.thumbs, job.err
+	}
+	job := &thumbnailJob{done: make(chan struct{})}
+	t.inflight[src] = job
+	t.mu.Unlock()
+
+	job.thumbs, job.err = t.render(src)
+
+	t.mu.Lock()
+	delete(t.inflight, src)
+	t.mu.Unlock()
+	close(job.done)
+
+	if job.err == nil {
+		t.Prune()
+	}
+	return job.thumbs, job.err
+}
+
+// Remove deletes the thumbnails and palette derived from src.
+func (t *Thumbnailer) Remove(src string) {
+	if t.dir == "" {
+		return
+	}
+	for _, size := range thumbnailSizes {
+		os.Remove(t.thumbnailPath(src, size))
+	}
+	os.Remove(t.palettePath(src))
+}
+
+// Prune deletes the least recently rendered thumbnail sets until the
+// directory fits the quota. The newest set is always kept.
+func (t *Thumbnailer) Prune() {
+	if t.dir == "" {
+		return
+	}
+
+	t.pruneMu.Lock()
+	defer t.pruneMu.Unlock()
+
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return
+	}
+
+	// The thumbnails and palette of one source share the hash prefix
+	type thumbnailSet struct {
+		files    []string
+		size     int64
+		modified time.Time
+	}
+	sets := make(map[string]*thumbnailSet)
+	var total int64
+	for _, entry := range entries {
+		name := entry.Name()
+		hash, _, ok := strings.Cut(name, "-")
+		if !ok || strings.HasPrefix(name, ".") || !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		set := sets[hash]
+		if set == nil {
+			set = &thumbnailSet{}
+			sets[hash] = set
+		}
+		set.files = append(set.files, name)
+		set.size += info.Size()
+		if info.ModTime().After(set.modified) {
+			set.modified = info.ModTime()
+		}
+		total += info.Size()
+	}
+	if total <= t.quota {
+		return
+	}
+
+	ordered := make([]*thumbnailSet, 0, len(sets))
+	for _, set := range sets {
+		ordered = append(ordered, set)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].modified.Before(ordered[j].modified)
+	})
+	for _, set := range ordered[:len(ordered)-1] {
+		if total <= t.quota {
+			break
+		}
+		for _, name := range set.files {
+			os.Remove(filepath.Join(t.dir, name))
+		}
+		total -= set.size
+	}
+}
+
+func (t *Thumbnailer) thumbnailPath(src string, size int) string {
+	return filepath.Join(t.dir, fmt.Sprintf("%x-%d.png", md5.Sum([]byte(src)), size))
+}
+
+func (t *Thumbnailer) render(src string) (map[string]string, error) {
+	if t.dir == "" {
+		return nil, fmt.Errorf("thumbnail directory not available")
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open art: %w", err)
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read art header: %v", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxArtSourcePixels {
+		return nil, fmt.Errorf("art dimensions not supported: %dx%d", cfg.Width, cfg.Height)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, fmt.Errorf("failed to rewind art: %v", err)
+	}
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode art: %v", err)
+	}
+
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create thumbnail directory: %v", err)
+	}
+
+	names := make([]string, 0, len(thumbnailSizes))
+	for name := range thumbnailSizes {
+		names = append(names, name)
+	}
+	// Render largest first and scale the smaller sizes from it, which keeps
+	// the expensive filter pass to one per cover.
+	sort.Slice(names, func(i, j int) bool {
+		return thumbnailSizes[names[i]] > thumbnailSizes[names[j]]
+	})
+
+	thumbs := make(map[string]string, len(names))
+	scaleSrc := squareCrop(img)
+	for _, name := range names {
+		size := thumbnailSizes[name]
+		thumb := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(thumb, thumb.Bounds(), scaleSrc, scaleSrc.Bounds(), draw.Src, nil)
+
+		path := t.thumbnailPath(src, size)
+		if err := writePNG(path, thumb); err != nil {
+			return nil, err
+		}
+		thumbs[name] = path
+		scaleSrc = thumb
+	}
+
+	return thumbs, nil
+}
+
+// squareCrop returns the centred square region of img.
+func squareCrop(img image.Image) image.Image {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	rect := image.Rect(x0, y0, x0+side, y0+side)
+
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Copy(dst, image.Point{}, img, rect, draw.Src, nil)
+	return dst
+}
+
+func writePNG(path string, img image.Image) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".thumb-*")
+	if err != nil {
+		return fmt.Errorf("failed to create thumbnail: %v", err)
+	}
+
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := enc.Encode(tmp, img); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to encode thumbnail: %v", err)
+	}
+	tmp.Close()
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save thumbnail: %v", err)
+	}
+	return nil
+}
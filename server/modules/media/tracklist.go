@@ -0,0 +1,327 @@
+package media
+
+import (
+	"dynamic-island-server/core"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	mprisTrackListInterface = "org.mpris.MediaPlayer2.TrackList"
+	mprisPlaylistsInterface = "org.mpris.MediaPlayer2.Playlists"
+	mprisNoTrack            = "/org/mpris/MediaPlayer2/TrackList/NoTrack"
+	maxPlaylists            = 200
+	maxTracks               = 500
+)
+
+type TrackEntry struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Artist  string `json:"artist"`
+	Album   string `json:"album"`
+	Length  int64  `json:"length"`
+	ArtUrl  string `json:"artUrl"`
+	Current bool   `json:"current"`
+}
+
+type PlaylistEntry struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Icon   string `json:"icon"`
+	Active bool   `json:"active"`
+}
+
+// mprisPlaylist mirrors the MPRIS Playlist struct (oss).
+type mprisPlaylist struct {
+	ID   dbus.ObjectPath
+	Name string
+	Icon string
+}
+
+func (s *MediaSource) trackEntry(id dbus.ObjectPath, metadata map[string]dbus.Variant) TrackEntry {
+	entry := TrackEntry{
+		ID:     string(id),
+		Title:  s.ExtractTitle(metadata),
+		Artist: s.ExtractArtist(metadata),
+		Album:  s.ExtractMetadataValue(metadata, []string{"xesam:album"}),
+		ArtUrl: s.ExtractArtUrl(metadata),
+	}
+	if v, ok := metadata["mpris:length"]; ok {
+		if l, ok := v.Value().(int64); ok {
+			entry.Length = l
+		}
+	}
+	return entry
+}
+
+func metadataTrackId(metadata map[string]dbus.Variant) dbus.ObjectPath {
+	if v, ok := metadata["mpris:trackid"]; ok {
+		switch id := v.Value().(type) {
+		case dbus.ObjectPath:
+			return id
+		case string:
+			return dbus.ObjectPath(id)
+		}
+	}
+	return ""
+}
+
+// trackWindow returns the bounds of the at most maxTracks of n tracks kept
+// around the track at index current, or the first ones when it is unknown.
+func trackWindow(n, current int) (int, int) {
+	if n <= maxTracks {
+		return 0, n
+	}
+	start := current - maxTracks/2
+	if start < 0 {
+		start = 0
+	}
+	if start > n-maxTracks {
+		start = n - maxTracks
+	}
+	return start, start + maxTracks
+}
+
+// loadTrackList fetches the track list of playerName, up to maxTracks around
+// the current track, if the player implements the TrackList interface,
+// otherwise clears it.
+func (s *MediaSource) loadTrackList(playerName string) {
+	obj := s.conn.Object(playerName, dbus.ObjectPath(mprisPath))
+
+	var tracks []TrackEntry
+	hasTrackList := false
+	if v, err := obj.GetProperty(mprisRootInterface + ".HasTrackList"); err == nil {
+		hasTrackList, _ = v.Value().(bool)
+	}
+
+	if hasTrackList {
+		var ids []dbus.ObjectPath
+		if v, err := obj.GetProperty(mprisTrackListInterface + ".Tracks"); err == nil {
+			ids, _ = v.Value().([]dbus.ObjectPath)
+		}
+		if len(ids) > maxTracks {
+			s.mu.Lock()
+			currentId := metadataTrackId(s.currentMetadata)
+			s.mu.Unlock()
+
+			current := -1
+			for i, id := range ids {
+				if id == currentId {
+					current = i
+					break
+				}
+			}
+			start, end := trackWindow(len(ids), current)
+			ids = ids[start:end]
+		}
+		if len(ids) > 0 {
+			var metas []map[string]dbus.Variant
+			if err := obj.Call(mprisTrackListInterface+".GetTracksMetadata", 0, ids).Store(&metas); err == nil {
+				for _, m := range metas {
+					tracks = append(tracks, s.trackEntry(metadataTrackId(m), m))
+				}
+			} else {
+				// log.Printf("⚠️ MediaSource: GetTracksMetadata failed: %v", err)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	if s.currentPlayer == playerName {
+		s.hasTrackList = hasTrackList
+		s.trackList = tracks
+	}
+	s.mu.Unlock()
+}
+
+func (s *MediaSource) handleTrackListSignal(signal *dbus.Signal, bus core.Bus) {
+	switch signal.Name {
+	case mprisTrackListInterface + ".TrackListReplaced":
+		s.loadTrackList(s.GetCurrentPlayer())
+
+	case mprisTrackListInterface + ".TrackAdded":
+		if len(signal.Body) < 2 {
+			return
+		}
+		metadata, ok := signal.Body[0].(map[string]dbus.Variant)
+		if !ok {
+			return
+		}
+		after, _ := signal.Body[1].(dbus.ObjectPath)
+		entry := s.trackEntry(metadataTrackId(metadata), metadata)
+
+		s.mu.Lock()
+		pos := 0
+		if after != mprisNoTrack {
+			for i, t := range s.trackList {
+				if t.ID == string(after) {
+					pos = i + 1
+					break
+				}
+			}
+		}
+		s.trackList = append(s.trackList, TrackEntry{})
+		copy(s.trackList[pos+1:], s.trackList[pos:])
+		s.trackList[pos] = entry
+
+		if len(s.trackList) > maxTracks {
+			currentId := string(metadataTrackId(s.currentMetadata))
+			current := -1
+			for i, t := range s.trackList {
+				if t.ID == currentId {
+					current = i
+					break
+				}
+			}
+			start, end := trackWindow(len(s.trackList), current)
+			s.trackList = s.trackList[start:end]
+		}
+		s.mu.Unlock()
+
+	case mprisTrackListInterface + ".TrackRemoved":
+		if len(signal.Body) < 1 {
+			return
+		}
+		id, ok := signal.Body[0].(dbus.ObjectPath)
+		if !ok {
+			return
+		}
+
+		s.mu.Lock()
+		for i, t := range s.trackList {
+			if t.ID == string(id) {
+				s.trackList = append(s.trackList[:i], s.trackList[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+
+	case mprisTrackListInterface + ".TrackMetadataChanged":
+		if len(signal.Body) < 2 {
+			return
+		}
+		id, ok := signal.Body[0].(dbus.ObjectPath)
+		if !ok {
+			return
+		}
+		metadata, ok := signal.Body[1].(map[string]dbus.Variant)
+		if !ok {
+			return
+		}
+		entry := s.trackEntry(metadataTrackId(metadata), metadata)
+		if entry.ID == "" {
+			entry.ID = string(id)
+		}
+
+		s.mu.Lock()
+		for i, t := range s.trackList {
+			if t.ID == string(id) {
+				s.trackList[i] = entry
+				break
+			}
+		}
+		s.mu.Unlock()
+
+	default:
+		return
+	}
+
+	s.publishTrackListChanged(bus)
+}
+
+func (s *MediaSource) publishTrackListChanged(bus core.Bus) {
+	s.mu.Lock()
+	playerName := s.currentPlayer
+	info := s.players[playerName]
+	count := len(s.trackList)
+	current := string(metadataTrackId(s.currentMetadata))
+	appName := playerDisplayName(info, playerName)
+	s.mu.Unlock()
+
+	if playerName == "" {
+		return
+	}
+
+	event := core.NewEvent(core.EventMediaTrackListChanged, appName, s.getPlayerPID(playerName))
+	event.Metadata["player"] = playerName
+	event.Metadata["count"] = count
+	event.Metadata["currentTrack"] = current
+	bus.Publish(event)
+}
+
+// GetTrackList returns the cached track list of the current player.
+func (s *MediaSource) GetTrackList() []TrackEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := string(metadataTrackId(s.currentMetadata))
+	tracks := make([]TrackEntry, len(s.trackList))
+	for i, t := range s.trackList {
+		t.Current = t.ID == current
+		tracks[i] = t
+	}
+	return tracks
+}
+
+// HasTrackList reports whether the current player implements TrackList.
+func (s *MediaSource) HasTrackList() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hasTrackList
+}
+
+// fetchPlaylists asks the player for its playlists and marks the active one.
+func fetchPlaylists(conn *dbus.Conn, playerName string) ([]PlaylistEntry, error) {
+	obj := conn.Object(playerName, dbus.ObjectPath(mprisPath))
+
+	count := uint32(0)
+	if v, err := obj.GetProperty(mprisPlaylistsInterface + ".PlaylistCount"); err == nil {
+		count, _ = v.Value().(uint32)
+	} else {
+		return nil, fmt.Errorf("player does not support playlists")
+	}
+	if count == 0 {
+		return []PlaylistEntry{}, nil
+	}
+	if count > maxPlaylists {
+		count = maxPlaylists
+	}
+
+	order := "UserDefined"
+	if v, err := obj.GetProperty(mprisPlaylistsInterface + ".Orderings"); err == nil {
+		if orderings, ok := v.Value().([]string); ok && len(orderings) > 0 {
+			order = orderings[0]
+		}
+	}
+
+	var raw []mprisPlaylist
+	if err := obj.Call(mprisPlaylistsInterface+".GetPlaylists", 0, uint32(0), count, order, false).Store(&raw); err != nil {
+		return nil, fmt.Errorf("failed to get playlists: %v", err)
+	}
+
+	active := ""
+	if v, err := obj.GetProperty(mprisPlaylistsInterface + ".ActivePlaylist"); err == nil {
+		// ActivePlaylist is (b(oss)): valid flag and the playlist.
+		if fields, ok := v.Value().([]interface{}); ok && len(fields) == 2 {
+			if valid, _ := fields[0].(bool); valid {
+				if pl, ok := fields[1].([]interface{}); ok && len(pl) == 3 {
+					if id, ok := pl[0].(dbus.ObjectPath); ok {
+						active = string(id)
+					}
+				}
+			}
+		}
+	}
+
+	playlists := make([]PlaylistEntry, 0, len(raw))
+	for _, p := range raw {
+		playlists = append(playlists, PlaylistEntry{
+			ID:     string(p.ID),
+			Name:   p.Name,
+			Icon:   p.Icon,
+			Active: string(p.ID) == active,
+		})
+	}
+	return playlists, nil
+}
@@ -0,0 +1,25 @@
+package media
+
+import "testing"
+
+func TestTrackWindow(t *testing.T) {
+	tests := []struct {
+		name       string
+		n, current int
+		start, end int
+	}{
+		{"short list", 10, 3, 0, 10},
+		{"exactly full", maxTracks, maxTracks - 1, 0, maxTracks},
+		{"unknown current", 2000, -1, 0, maxTracks},
+		{"current near start", 2000, 100, 0, maxTracks},
+		{"current in the middle", 2000, 1000, 1000 - maxTracks/2, 1000 + maxTracks/2},
+		{"current near end", 2000, 1990, 2000 - maxTracks, 2000},
+	}
+	for _, tt := range tests {
+		start, end := trackWindow(tt.n, tt.current)
+		if start != tt.start || end != tt.end {
+			t.Errorf("%s: trackWindow(%d, %d) = %d, %d, want %d, %d",
+				tt.name, tt.n, tt.current, start, end, tt.start, tt.end)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package media
+
+import (
+	"dynamic-island-server/modules/audio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const maxParentDepth = 8
+
+// SetVolume sets the current player's own volume (0-100) through the MPRIS
+// Volume property, or through its sink-input when the player lacks one.
+func (s *MediaService) SetVolume(level int32) error {
+	playerName, err := s.getCurrentPlayer()
+	if err != nil {
+		return err
+	}
+
+	if level < 0 {
+		level = 0
+	}
+	if level > 100 {
+		level = 100
+	}
+
+	obj := s.conn.Object(playerName, dbus.ObjectPath(mprisPath))
+	if err := obj.SetProperty(mprisPlayerInterface+".Volume", dbus.MakeVariant(float64(level)/100)); err == nil {
+		return nil
+	}
+
+	input, err := s.playerSinkInput(playerName)
+	if err != nil {
+		return err
+	}
+
+	if err := s.audio.SetSinkInputVolume(input.Index, audio.UniformVolume(len(input.Volume), int(level))); err != nil {
+		return fmt.Errorf("failed to set stream volume: %v", err)
+	}
+	return nil
+}
+
+// GetVolume returns the current player's own volume (0-100).
+func (s *MediaService) GetVolume() (int32, error) {
+	playerName, err := s.getCurrentPlayer()
+	if err != nil {
+		return 0, err
+	}
+
+	obj := s.conn.Object(playerName, dbus.ObjectPath(mprisPath))
+	if v, err := obj.GetProperty(mprisPlayerInterface + ".Volume"); err == nil {
+		if vol, ok := v.Value().(float64); ok {
+			return int32(math.Round(vol * 100)), nil
+		}
+	}
+
+	input, err := s.playerSinkInput(playerName)
+	if err != nil {
+		return 0, err
+	}
+	return int32(input.Level()), nil
+}
+
+// playerSinkInput finds the sink-input belonging to the player's process or
+// one of its children (browsers play audio from a helper process).
+func (s *MediaService) playerSinkInput(playerName string) (*audio.SinkInput, error) {
+	pid := s.mediaSource.getPlayerPID(playerName)
+	if pid <= 0 {
+		return nil, fmt.Errorf("player PID not available")
+	}
+
+	inputs, err := s.audio.SinkInputs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sink inputs: %v", err)
+	}
+
+	for i := range inputs {
+		if inputs[i].PID() == pid {
+			return &inputs[i], nil
+		}
+	}
+	for i := range inputs {
+		if isDescendantOf(inputs[i].PID(), pid) {
+			return &inputs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no audio stream for player")
+}
+
+// isDescendantOf walks the parent chain of pid looking for ancestor.
+func isDescendantOf(pid, ancestor int) bool {
+	for depth := 0; pid > 1 && depth < maxParentDepth; depth++ {
+		ppid := parentPID(pid)
+		if ppid == ancestor {
+			return true
+		}
+		pid = ppid
+	}
+	return false
+}
+
+func parentPID(pid int) int {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0
+	}
+	// The comm field may contain spaces; fields resume after the last ')'.
+	stat := string(data)
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return 0
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	return ppid
+}
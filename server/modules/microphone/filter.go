@@ -0,0 +1,153 @@
+package microphone
+
+import (
+	"dynamic-island-server/core"
+	"dynamic-island-server/modules/audio"
+	"os"
+	"path"
+	"strings"
+)
+
+// Reasons a capture stream is not reported as recording.
+const (
+	reasonSelf       = "own stream"
+	reasonNoApp      = "no application"
+	reasonMonitor    = "monitor source"
+	reasonIgnoreRule = "ignore rule"
+)
+
+// recorder is a capture stream and the application it is attributed to.
+type recorder struct {
+	output audio.SourceOutput
+	app    string
+	pid    int
+	// source is the device the stream captures from; nil if it vanished
+	// between the two queries.
+	source *audio.Source
+	// filtered is the reason the stream is not reported, empty if it is.
+	filtered string
+	rule     *core.MicrophoneRule
+}
+
+// appFilter applies the configured allow and ignore rules.
+type appFilter struct {
+	allow           []core.MicrophoneRule
+	ignore          []core.MicrophoneRule
+	includeMonitors bool
+}
+
+func newAppFilter(cfg core.MicrophoneConfig) appFilter {
+	return appFilter{
+		allow:           cfg.AllowApps,
+		ignore:          cfg.IgnoreApps,
+		includeMonitors: cfg.IncludeMonitors,
+	}
+}
+
+// match returns the ignore rule that filters the stream, or nil if it counts
+// as recording.
+func (f appFilter) match(app string, props map[string]string) *core.MicrophoneRule {
+	for i := range f.allow {
+		if matchAppRule(f.allow[i], app, props) {
+			return nil
+		}
+	}
+	for i := range f.ignore {
+		if matchAppRule(f.ignore[i], app, props) {
+			return &f.ignore[i]
+		}
+	}
+	return nil
+}
+
+// listRecorders returns every capture stream, marking the ones that do not
+// count as an app recording.
+func listRecorders(backend *audio.Backend, filter appFilter) ([]recorder, error) {
+	outputs, err := backend.SourceOutputs()
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[uint32]*audio.Source)
+	if list, err := backend.Sources(); err == nil {
+		for i := range list {
+			sources[list[i].Index] = &list[i]
+		}
+	}
+
+	self := os.Getpid()
+	var clients map[uint32]string
+	results := make([]recorder, 0, len(outputs))
+	for _, output := range outputs {
+		r := recorder{
+			output: output,
+			app:    output.Props["application.name"],
+			pid:    output.PID(),
+			source: sources[output.Source],
+		}
+
+		// Fall back to the client name like pactl's "Client Name:"
+		if r.app == "" {
+			if clients == nil {
+				clients = clientNames(backend)
+			}
+			r.app = clients[output.Client]
+		}
+
+		switch {
+		case r.pid == self:
+			r.filtered = reasonSelf
+		case r.app == "" && r.pid == 0:
+			r.filtered = reasonNoApp
+		case r.source != nil && r.source.IsMonitor() && !filter.includeMonitors:
+			r.filtered = reasonMonitor
+		default:
+			if r.app == "" {
+				r.app = "unknown"
+			}
+			if r.rule = filter.match(r.app, output.Props); r.rule != nil {
+				r.filtered = reasonIgnoreRule
+			}
+		}
+
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func clientNames(backend *audio.Backend) map[uint32]string {
+	names := make(map[uint32]string)
+	clients, err := backend.Clients()
+	if err != nil {
+		return names
+	}
+	for _, c := range clients {
+		names[c.Index] = c.Name
+	}
+	return names
+}
+
+// matchAppRule reports whether every field set in rule matches the stream.
+func matchAppRule(rule core.MicrophoneRule, app string, props map[string]string) bool {
+	if rule.AppName == "" && rule.Binary == "" && rule.Role == "" && rule.Class == "" {
+		return false
+	}
+	if rule.AppName != "" && !matchGlob(rule.AppName, app) {
+		return false
+	}
+	if rule.Binary != "" && !matchGlob(rule.Binary, props["application.process.binary"]) {
+		return false
+	}
+	if rule.Role != "" && !matchGlob(rule.Role, props["media.role"]) {
+		return false
+	}
+	if rule.Class != "" && !matchGlob(rule.Class, props["media.class"]) {
+		return false
+	}
+	return true
+}
+
+func matchGlob(pattern, value string) bool {
+	ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && ok
+}
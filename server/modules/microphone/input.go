@@ -0,0 +1,76 @@
+package microphone
+
+import (
+	"dynamic-island-server/core"
+	"dynamic-island-server/modules/audio"
+)
+
+// inputState is the last seen volume and mute of the default source.
+type inputState struct {
+	source      string
+	description string
+	level       int
+	muted       bool
+	known       bool
+}
+
+// watchInput publishes mute and volume changes of the default source.
+// Switching to another source only records its state.
+func (s *MicrophoneSource) watchInput(bus core.Bus) {
+	source, err := s.backend.Source(audio.DefaultSource)
+	if err != nil || source.IsMonitor() {
+		return
+	}
+
+	s.inputMu.Lock()
+	old := s.input
+	s.input = inputState{
+		source:      source.Name,
+		description: source.Description,
+		level:       source.Level(),
+		muted:       source.Muted,
+		known:       true,
+	}
+	current := s.input
+	s.inputMu.Unlock()
+
+	if !old.known {
+		return
+	}
+	if old.source != current.source {
+		s.mu.Lock()
+		s.updateMeter(bus)
+		s.mu.Unlock()
+		return
+	}
+
+	var eventType core.EventType
+	switch {
+	case old.muted != current.muted:
+		if current.muted {
+			eventType = core.EventMicrophoneMuted
+		} else {
+			eventType = core.EventMicrophoneUnmuted
+		}
+	case old.level != current.level:
+		eventType = core.EventMicrophoneVolumeChanged
+	default:
+		return
+	}
+
+	event := core.NewEvent(eventType, "microphone", 0)
+	event.Metadata["level"] = current.level
+	event.Metadata["muted"] = current.muted
+	event.Metadata["source"] = current.source
+	event.Metadata["description"] = current.description
+	event.Metadata["old_level"] = old.level
+	event.Metadata["old_muted"] = old.muted
+	bus.Publish(event)
+}
+
+// inputMuted reports whether the default source is muted.
+func (s *MicrophoneSource) inputMuted() bool {
+	s.inputMu.Lock()
+	defer s.inputMu.Unlock()
+	return s.input.muted
+}
@@ -0,0 +1,130 @@
+package microphone
+
+import (
+	"dynamic-island-server/core"
+	"dynamic-island-server/modules/audio"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// Peak-detect rate of the meter stream; each sample is the input peak
+	// over 10ms, so the stream costs ~400 bytes/s.
+	levelSampleRate = 100
+	// levelInterval throttles microphone_level to ~12 events per second.
+	levelInterval = 80 * time.Millisecond
+)
+
+// levelMeter reads the default source through a peak-detect stream while
+// any app records and publishes the smoothed level.
+type levelMeter struct {
+	mu     sync.Mutex
+	stream *audio.RecordStream
+	source string
+
+	peak   float64
+	sumSq  float64
+	count  int
+	window time.Time
+}
+
+// updateMeter starts the meter when recording begins and stops it when the
+// last recording app goes away. Called with s.mu held.
+func (s *MicrophoneSource) updateMeter(bus core.Bus) {
+	recording := len(s.activeApps) > 0
+
+	s.meter.mu.Lock()
+	running := s.meter.stream != nil
+	source := s.meter.source
+	s.meter.mu.Unlock()
+
+	s.inputMu.Lock()
+	input := s.input.source
+	s.inputMu.Unlock()
+
+	switch {
+	case recording && running && source != input && input != "":
+		// Default source switched mid-recording; follow it
+		s.stopMeter()
+		s.startMeter(bus, input)
+	case recording && !running && input != "":
+		s.startMeter(bus, input)
+	case !recording && running:
+		s.stopMeter()
+	}
+}
+
+func (s *MicrophoneSource) startMeter(bus core.Bus, source string) {
+	m := &s.meter
+	stream, err := s.backend.Record(audio.RecordOptions{
+		Source:       source,
+		Rate:         levelSampleRate,
+		PeakDetect:   true,
+		FragmentSize: 4 * levelSampleRate / 25,
+		Hidden:       true,
+		Props:        map[string]string{"media.name": "Microphone level"},
+	}, func(samples []float32) {
+		m.add(bus, samples)
+	}, func() {
+		m.mu.Lock()
+		m.stream = nil
+		m.mu.Unlock()
+	})
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	m.stream = stream
+	m.source = source
+	m.peak, m.sumSq, m.count = 0, 0, 0
+	m.window = time.Now()
+	m.mu.Unlock()
+}
+
+func (s *MicrophoneSource) stopMeter() {
+	m := &s.meter
+	m.mu.Lock()
+	stream := m.stream
+	m.stream = nil
+	m.mu.Unlock()
+
+	if stream != nil {
+		stream.Close()
+	}
+}
+
+// add folds a packet into the current window and publishes once the window
+// is older than levelInterval.
+func (m *levelMeter) add(bus core.Bus, samples []float32) {
+	peak, rms := audio.PeakAndRMS(samples)
+
+	m.mu.Lock()
+	if m.stream == nil {
+		m.mu.Unlock()
+		return
+	}
+	if peak > m.peak {
+		m.peak = peak
+	}
+	m.sumSq += rms * rms * float64(len(samples))
+	m.count += len(samples)
+
+	if time.Since(m.window) < levelInterval || m.count == 0 {
+		m.mu.Unlock()
+		return
+	}
+	peak = m.peak
+	rms = math.Sqrt(m.sumSq / float64(m.count))
+	source := m.source
+	m.peak, m.sumSq, m.count = 0, 0, 0
+	m.window = time.Now()
+	m.mu.Unlock()
+
+	event := core.NewEvent(core.EventMicrophoneLevel, "microphone", 0)
+	event.Metadata["peak"] = math.Round(math.Min(peak, 1)*1000) / 1000
+	event.Metadata["rms"] = math.Round(math.Min(rms, 1)*1000) / 1000
+	event.Metadata["source"] = source
+	bus.Publish(event)
+}
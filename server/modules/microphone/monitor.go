@@ -1,28 +1,33 @@
 package microphone
 
 import (
-	"bufio"
-	"bytes"
 	"dynamic-island-server/core"
+	"dynamic-island-server/modules/audio"
 	"fmt"
-	"os/exec"
-	"regexp"
 	"strconv"
 	"strings"
 	"sync"
-	"time"
 )
 
 type MicrophoneSource struct {
 	activeApps  map[string]bool
 	mu          sync.Mutex
 	initialized bool
+	backend     *audio.Backend
+	filter      appFilter
+
+	inputMu sync.Mutex
+	input   inputState
+
+	meter levelMeter
 }
 
-func NewMicrophoneSource() *MicrophoneSource {
+func NewMicrophoneSource(backend *audio.Backend, cfg core.MicrophoneConfig) *MicrophoneSource {
 	return &MicrophoneSource{
 		activeApps:  make(map[string]bool),
 		initialized: false,
+		backend:     backend,
+		filter:      newAppFilter(cfg),
 	}
 }
 
@@ -32,34 +37,29 @@ func (s *MicrophoneSource) GetName() string {
 
 func (s *MicrophoneSource) Start(bus core.Bus, stopChan <-chan struct{}) error {
 	// Initialize activeApps without publishing events (silent initialization)
+	s.watchInput(bus)
 	s.initializeActiveApps()
 
-	cmd := exec.Command("pactl", "subscribe")
-	cmd.Env = append(cmd.Environ(), "LC_ALL=C")
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return err
-	}
-
-	if err := cmd.Start(); err != nil {
-		return err
-	}
-
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if strings.Contains(line, "source-output") {
-
-				time.Sleep(100 * time.Millisecond)
-				s.checkAndPublish(bus)
-			}
+	s.mu.Lock()
+	s.updateMeter(bus)
+	s.mu.Unlock()
+
+	unsubscribe := s.backend.Subscribe(audio.MaskSourceOutput, func(ev audio.SubscriptionEvent) {
+		s.checkAndPublish(bus)
+	})
+	// Default source switches arrive as server changes
+	unsubscribeInput := s.backend.Subscribe(audio.MaskSource|audio.MaskServer, func(ev audio.SubscriptionEvent) {
+		if ev.Facility == audio.FacilitySource && ev.Type == audio.EventRemove {
+			return
 		}
-	}()
+		s.watchInput(bus)
+	})
 
 	go func() {
 		<-stopChan
-		cmd.Process.Kill()
+		unsubscribe()
+		unsubscribeInput()
+		s.stopMeter()
 	}()
 
 	return nil
@@ -69,7 +69,7 @@ func (s *MicrophoneSource) initializeActiveApps() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	current := getMicrophoneApps()
+	current := s.getMicrophoneApps()
 	for _, app := range current {
 		key := fmt.Sprintf("%s:%d", app.AppName, app.PID)
 		s.activeApps[key] = true
@@ -87,7 +87,7 @@ func (s *MicrophoneSource) checkAndPublish(bus core.Bus) {
 		return
 	}
 
-	current := getMicrophoneApps()
+	current := s.getMicrophoneApps()
 	currentMap := make(map[string]bool)
 
 	for _, app := range current {
@@ -98,6 +98,15 @@ func (s *MicrophoneSource) checkAndPublish(bus core.Bus) {
 			s.activeApps[key] = true
 			event := core.NewEvent(core.EventMicrophoneStart, app.AppName, app.PID)
 			event.Metadata["device"] = "microphone"
+			event.Metadata["muted"] = s.inputMuted()
+			if source := app.Source; source != nil {
+				event.Metadata["muted"] = source.Muted
+				event.Metadata["source"] = source.Name
+				event.Metadata["description"] = source.Description
+				if deviceBus := source.Props["device.bus"]; deviceBus != "" {
+					event.Metadata["bus"] = deviceBus
+				}
+			}
 			bus.Publish(event)
 		}
 	}
@@ -115,99 +124,29 @@ func (s *MicrophoneSource) checkAndPublish(bus core.Bus) {
 			}
 		}
 	}
+
+	s.updateMeter(bus)
 }
 
 type AppInfo struct {
 	AppName string
 	PID     int
+	// Source is the capture device, nil if unknown.
+	Source *audio.Source
 }
 
-func getMicrophoneApps() []AppInfo {
-	cmd := exec.Command("pactl", "list", "source-outputs")
-	cmd.Env = append(cmd.Environ(), "LC_ALL=C")
-	output, err := cmd.Output()
+func (s *MicrophoneSource) getMicrophoneApps() []AppInfo {
+	recorders, err := listRecorders(s.backend, s.filter)
 	if err != nil {
 		return []AppInfo{}
 	}
-	return parsePactlOutput(string(output))
-}
 
-func parsePactlOutput(output string) []AppInfo {
 	var results []AppInfo
-	var curBlock bytes.Buffer
-	inBlock := false
-
-	for _, line := range strings.Split(output, "\n") {
-		if strings.HasPrefix(line, "Source Output #") {
-			if inBlock {
-				if bi := parsePactlBlock(curBlock.String()); bi != nil {
-					results = append(results, *bi)
-				}
-				curBlock.Reset()
-			}
-			inBlock = true
-		}
-		if inBlock {
-			curBlock.WriteString(line + "\n")
+	for _, r := range recorders {
+		if r.filtered != "" {
+			continue
 		}
+		results = append(results, AppInfo{AppName: r.app, PID: r.pid, Source: r.source})
 	}
-
-	if inBlock && curBlock.Len() > 0 {
-		if bi := parsePactlBlock(curBlock.String()); bi != nil {
-			results = append(results, *bi)
-		}
-	}
-
 	return results
 }
-
-var reAppName = regexp.MustCompile(`application.name\s*=\s*"([^"]+)"`)
-var rePID = regexp.MustCompile(`application.process.id\s*=\s*"?(\d+)"?`)
-
-// Blacklist of apps that should NOT trigger microphone recording events
-// These are typically audio processors, effects, or system components
-var microphoneBlacklist = map[string]bool{
-	"PulseEffects":     true,
-	"pulseeffects":     true,
-	"EasyEffects":      true,
-	"easyeffects":      true,
-	"PulseAudio":       true,
-	"pulseaudio":       true,
-	"PipeWire":         true,
-	"pipewire":         true,
-	"GNOME Shell":      true,
-	"gnome-shell":      true,
-}
-
-func parsePactlBlock(block string) *AppInfo {
-	app := "unknown"
-	pid := 0
-
-	if m := reAppName.FindStringSubmatch(block); len(m) == 2 {
-		app = m[1]
-	}
-	if m := rePID.FindStringSubmatch(block); len(m) == 2 {
-		pid, _ = strconv.Atoi(m[1])
-	}
-
-	if app == "unknown" {
-		for _, l := range strings.Split(block, "\n") {
-			l = strings.TrimSpace(l)
-			if strings.HasPrefix(l, "Client Name:") {
-				app = strings.TrimSpace(strings.TrimPrefix(l, "Client Name:"))
-				break
-			}
-		}
-	}
-
-	if app == "unknown" && pid == 0 {
-		return nil
-	}
-
-	// Filter out blacklisted apps
-	if microphoneBlacklist[app] {
-		return nil
-	}
-
-	return &AppInfo{AppName: app, PID: pid}
-}
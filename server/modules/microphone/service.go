@@ -0,0 +1,115 @@
+package microphone
+
+import (
+	"dynamic-island-server/core"
+	"dynamic-island-server/modules/audio"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+type MicrophoneService struct {
+	backend *audio.Backend
+	filter  appFilter
+	mu      sync.Mutex
+}
+
+func NewMicrophoneService(backend *audio.Backend, cfg core.MicrophoneConfig) *MicrophoneService {
+	return &MicrophoneService{backend: backend, filter: newAppFilter(cfg)}
+}
+
+// StreamDiagnostic describes a capture stream and whether it counts as an
+// app recording.
+type StreamDiagnostic struct {
+	ID       uint32               `json:"id"`
+	App      string               `json:"app"`
+	PID      int                  `json:"pid"`
+	Binary   string               `json:"binary,omitempty"`
+	Role     string               `json:"role,omitempty"`
+	Class    string               `json:"class,omitempty"`
+	Source   string               `json:"source,omitempty"`
+	Filtered string               `json:"filtered,omitempty"`
+	Rule     *core.MicrophoneRule `json:"rule,omitempty"`
+}
+
+// GetMicrophoneDiagnostics returns every capture stream as JSON with the
+// reason and rule that filtered it, if any.
+func (s *MicrophoneService) GetMicrophoneDiagnostics() (string, error) {
+	recorders, err := listRecorders(s.backend, s.filter)
+	if err != nil {
+		return "", fmt.Errorf("failed to list capture streams: %v", err)
+	}
+
+	streams := make([]StreamDiagnostic, 0, len(recorders))
+	for _, r := range recorders {
+		var source string
+		if r.source != nil {
+			source = r.source.Name
+		}
+		streams = append(streams, StreamDiagnostic{
+			ID:       r.output.Index,
+			App:      r.app,
+			PID:      r.pid,
+			Binary:   r.output.Props["application.process.binary"],
+			Role:     r.output.Props["media.role"],
+			Class:    r.output.Props["media.class"],
+			Source:   source,
+			Filtered: r.filtered,
+			Rule:     r.rule,
+		})
+	}
+
+	data, err := json.Marshal(streams)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode capture streams: %v", err)
+	}
+	return string(data), nil
+}
+
+func (s *MicrophoneService) defaultSource() (audio.Source, error) {
+	source, err := s.backend.Source(audio.DefaultSource)
+	if err != nil {
+		return source, fmt.Errorf("no default microphone: %v", err)
+	}
+	if source.IsMonitor() {
+		return source, fmt.Errorf("default input %s is a monitor, not a microphone", source.Name)
+	}
+	return source, nil
+}
+
+// ToggleMicMute flips the mute of the default source and returns the new state.
+func (s *MicrophoneService) ToggleMicMute() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, err := s.defaultSource()
+	if err != nil {
+		return false, err
+	}
+	if err := s.backend.SetSourceMute(source.Name, !source.Muted); err != nil {
+		return source.Muted, fmt.Errorf("failed to toggle microphone mute: %v", err)
+	}
+	return !source.Muted, nil
+}
+
+// SetMicVolume sets the default source volume (0-100), keeping its balance.
+func (s *MicrophoneService) SetMicVolume(level int32) error {
+	if level < 0 {
+		level = 0
+	}
+	if level > 100 {
+		level = 100
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, err := s.defaultSource()
+	if err != nil {
+		return err
+	}
+	if err := s.backend.SetSourceVolume(source.Name, audio.ScaleVolume(source.Volume, int(level))); err != nil {
+		return fmt.Errorf("failed to set microphone volume: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+package pipewire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Object types reported in registry globals.
+const (
+	TypeNode   = "PipeWire:Interface:Node"
+	TypeLink   = "PipeWire:Interface:Link"
+	TypeClient = "PipeWire:Interface:Client"
+)
+
+// Fixed proxy IDs of every connection.
+const (
+	coreID     = 0
+	clientID   = 1
+	registryID = 2
+)
+
+const (
+	coreVersion     = 4
+	registryVersion = 3
+	clientVersion   = 3
+
+	headerSize = 16
+	maxPayload = 0xFFFFFF
+)
+
+// Opcodes of the methods and events used.
+const (
+	coreMethodHello              = 1
+	coreMethodPong               = 3
+	coreMethodGetRegistry        = 5
+	clientMethodUpdateProperties = 2
+	registryMethodBind           = 1
+
+	coreEventPing             = 2
+	coreEventRemoveID         = 4
+	registryEventGlobal       = 0
+	registryEventGlobalRemove = 1
+	clientEventInfo           = 0
+)
+
+// Global is an object announced by the registry.
+type Global struct {
+	ID    uint32
+	Type  string
+	Props map[string]string
+}
+
+// Conn is a native protocol connection that mirrors the registry. Client
+// objects are bound so their full properties, such as the portal app ID,
+// are known; other globals carry only the properties the registry shares.
+type Conn struct {
+	conn net.Conn
+
+	writeMu sync.Mutex
+	seq     uint32
+
+	mu       sync.Mutex
+	globals  map[uint32]*Global
+	proxies  map[uint32]uint32 // proxy ID -> global ID
+	usedIDs  map[uint32]bool
+	onChange func()
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// socketPath resolves the daemon socket like libpipewire does.
+func socketPath() string {
+	name := os.Getenv("PIPEWIRE_REMOTE")
+	if name == "" {
+		name = "pipewire-0"
+	}
+	if filepath.IsAbs(name) {
+		return name
+	}
+
+	dir := os.Getenv("PIPEWIRE_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.Getenv("XDG_RUNTIME_DIR")
+	}
+	if dir == "" {
+		dir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+	return filepath.Join(dir, name)
+}
+
+// Dial connects to the PipeWire daemon. onChange runs on the reader
+// goroutine after every registry or client update and must not block.
+func Dial(appName string, onChange func()) (*Conn, error) {
+	conn, err := net.Dial("unix", socketPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to pipewire: %v", err)
+	}
+
+	c := &Conn{
+		conn:     conn,
+		globals:  make(map[uint32]*Global),
+		proxies:  make(map[uint32]uint32),
+		usedIDs:  map[uint32]bool{coreID: true, clientID: true, registryID: true},
+		onChange: onChange,
+		done:     make(chan struct{}),
+	}
+
+	hello := new(podWriter).int(coreVersion)
+	props := new(podWriter).dict(map[string]string{
+		"application.name":       appName,
+		"application.process.id": fmt.Sprint(os.Getpid()),
+	})
+	registry := new(podWriter).int(registryVersion).int(registryID)
+
+	for _, msg := range []struct {
+		id, opcode uint32
+		args       *podWriter
+	}{
+		{coreID, coreMethodHello, hello},
+		{clientID, clientMethodUpdateProperties, props},
+		{coreID, coreMethodGetRegistry, registry},
+	} {
+		if err := c.send(msg.id, msg.opcode, msg.args); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("pipewire handshake failed: %v", err)
+		}
+	}
+
+	go c.readLoop()
+	return c, nil
+}
+
+// Globals returns a copy of the known globals of the given type.
+func (c *Conn) Globals(typ string) []Global {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var out []Global
+	for _, g := range c.globals {
+		if g.Type == typ {
+			out = append(out, copyGlobal(g))
+		}
+	}
+	return out
+}
+
+// Global returns the global with the given ID.
+func (c *Conn) Global(id uint32) (Global, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	g, ok := c.globals[id]
+	if !ok {
+		return Global{}, false
+	}
+	return copyGlobal(g), true
+}
+
+// Done is closed when the connection drops.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Conn) Close() {
+	c.closeOnce.Do(func() {
+		c.conn.Close()
+		close(c.done)
+	})
+}
+
+func copyGlobal(g *Global) Global {
+	props := make(map[string]string, len(g.Props))
+	for k, v := range g.Props {
+		props[k] = v
+	}
+	return Global{ID: g.ID, Type: g.Type, Props: props}
+}
+
+func (c *Conn) send(id, opcode uint32, args *podWriter) error {
+	payload := args.structBytes()
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	msg := make([]byte, headerSize, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(msg[0:], id)
+	binary.LittleEndian.PutUint32(msg[4:], opcode<<24|uint32(len(payload)))
+	binary.LittleEndian.PutUint32(msg[8:], c.seq)
+	binary.LittleEndian.PutUint32(msg[12:], 0)
+	c.seq++
+
+	_, err := c.conn.Write(append(msg, payload...))
+	return err
+}
+
+func (c *Conn) readLoop() {
+	defer c.Close()
+
+	r := bufio.NewReader(c.conn)
+	header := make([]byte, headerSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return
+		}
+		id := binary.LittleEndian.Uint32(header[0:])
+		word := binary.LittleEndian.Uint32(header[4:])
+		opcode, size := word>>24, word&maxPayload
+
+		// Messages may carry a footer after the payload Struct; the reader
+		// only looks at the Struct.
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return
+		}
+		c.handle(id, opcode, payload)
+	}
+}
+
+func (c *Conn) handle(id, opcode uint32, payload []byte) {
+	args := newStructReader(payload)
+
+	switch {
+	case id == coreID && opcode == coreEventPing:
+		pingID, seq := args.int(), args.int()
+		if args.err == nil {
+			c.send(coreID, coreMethodPong, new(podWriter).int(pingID).int(seq))
+		}
+
+	case id == coreID && opcode == coreEventRemoveID:
+		proxy := uint32(args.int())
+		if args.err == nil {
+			c.mu.Lock()
+			delete(c.usedIDs, proxy)
+			delete(c.proxies, proxy)
+			c.mu.Unlock()
+		}
+
+	case id == registryID && opcode == registryEventGlobal:
+		g := &Global{ID: uint32(args.int())}
+		args.int() // permissions
+		g.Type = args.str()
+		args.int() // version
+		g.Props = args.dict()
+		if args.err != nil {
+			return
+		}
+		c.addGlobal(g)
+		c.changed()
+
+	case id == registryID && opcode == registryEventGlobalRemove:
+		gid := uint32(args.int())
+		if args.err != nil {
+			return
+		}
+		c.mu.Lock()
+		delete(c.globals, gid)
+		c.mu.Unlock()
+		c.changed()
+
+	case id > registryID && opcode == clientEventInfo:
+		args.int()  // id
+		args.long() // change mask
+		props := args.dict()
+		if args.err != nil {
+			return
+		}
+
+		c.mu.Lock()
+		gid, ok := c.proxies[id]
+		g := c.globals[gid]
+		if ok && g != nil {
+			for k, v := range props {
+				g.Props[k] = v
+			}
+		}
+		c.mu.Unlock()
+		if ok {
+			c.changed()
+		}
+	}
+}
+
+// addGlobal records a global and binds client objects to learn their full
+// properties.
+func (c *Conn) addGlobal(g *Global) {
+	c.mu.Lock()
+	if g.Props == nil {
+		g.Props = make(map[string]string)
+	}
+	c.globals[g.ID] = g
+	if g.Type != TypeClient {
+		c.mu.Unlock()
+		return
+	}
+
+	// The server keeps proxy IDs in a dense table, so reuse the lowest
+	// free one.
+	proxy := uint32(registryID + 1)
+	for c.usedIDs[proxy] {
+		proxy++
+	}
+	c.usedIDs[proxy] = true
+	c.proxies[proxy] = g.ID
+	c.mu.Unlock()
+
+	bind := new(podWriter).int(int32(g.ID)).str(TypeClient).int(clientVersion).int(int32(proxy))
+	c.send(registryID, registryMethodBind, bind)
+}
+
+func (c *Conn) changed() {
+	if c.onChange != nil {
+		c.onChange()
+	}
+}
@@ -0,0 +1,181 @@
+package pipewire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// SPA POD types used by the core, registry and client interfaces.
+const (
+	podNone   = 1
+	podInt    = 4
+	podLong   = 5
+	podString = 8
+	podStruct = 14
+)
+
+var errShortPod = errors.New("pipewire: truncated pod")
+
+// podWriter builds a POD Struct of values.
+type podWriter struct {
+	buf []byte
+}
+
+func (w *podWriter) header(size, typ uint32) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, size)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, typ)
+}
+
+func (w *podWriter) pad() {
+	for len(w.buf)%8 != 0 {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+func (w *podWriter) int(v int32) *podWriter {
+	w.header(4, podInt)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(v))
+	w.pad()
+	return w
+}
+
+func (w *podWriter) str(s string) *podWriter {
+	w.header(uint32(len(s)+1), podString)
+	w.buf = append(w.buf, s...)
+	w.buf = append(w.buf, 0)
+	w.pad()
+	return w
+}
+
+// dict writes a spa_dict as a Struct of the item count and key/value pairs.
+func (w *podWriter) dict(items map[string]string) *podWriter {
+	inner := new(podWriter)
+	inner.int(int32(len(items)))
+	for k, v := range items {
+		inner.str(k).str(v)
+	}
+	return w.nest(inner)
+}
+
+// nest appends inner wrapped in a Struct.
+func (w *podWriter) nest(inner *podWriter) *podWriter {
+	w.header(uint32(len(inner.buf)), podStruct)
+	w.buf = append(w.buf, inner.buf...)
+	return w
+}
+
+// structBytes returns the values wrapped in the outer Struct every message
+// payload is.
+func (w *podWriter) structBytes() []byte {
+	return new(podWriter).nest(w).buf
+}
+
+// podReader reads the values of a Struct in order. The first error sticks
+// and later reads return zero values.
+type podReader struct {
+	buf []byte
+	err error
+}
+
+// newStructReader opens the Struct at the start of data.
+func newStructReader(data []byte) *podReader {
+	r := &podReader{buf: data}
+	typ, body := r.next()
+	if r.err == nil && typ != podStruct {
+		r.err = fmt.Errorf("pipewire: expected struct, got type %d", typ)
+	}
+	return &podReader{buf: body, err: r.err}
+}
+
+// next returns the type and body of the next value.
+func (r *podReader) next() (uint32, []byte) {
+	if r.err != nil {
+		return 0, nil
+	}
+	if len(r.buf) < 8 {
+		r.err = errShortPod
+		return 0, nil
+	}
+	size := binary.LittleEndian.Uint32(r.buf)
+	typ := binary.LittleEndian.Uint32(r.buf[4:])
+	padded := (int(size) + 7) &^ 7
+	if int(size) > len(r.buf)-8 {
+		r.err = errShortPod
+		return 0, nil
+	}
+	body := r.buf[8 : 8+size]
+	if 8+padded > len(r.buf) {
+		padded = len(r.buf) - 8
+	}
+	r.buf = r.buf[8+padded:]
+	return typ, body
+}
+
+func (r *podReader) int() int32 {
+	typ, body := r.next()
+	if r.err != nil {
+		return 0
+	}
+	if typ != podInt || len(body) < 4 {
+		r.err = fmt.Errorf("pipewire: expected int, got type %d", typ)
+		return 0
+	}
+	return int32(binary.LittleEndian.Uint32(body))
+}
+
+func (r *podReader) long() int64 {
+	typ, body := r.next()
+	if r.err != nil {
+		return 0
+	}
+	if typ != podLong || len(body) < 8 {
+		r.err = fmt.Errorf("pipewire: expected long, got type %d", typ)
+		return 0
+	}
+	return int64(binary.LittleEndian.Uint64(body))
+}
+
+// str reads a String; None reads as "".
+func (r *podReader) str() string {
+	typ, body := r.next()
+	if r.err != nil || typ == podNone {
+		return ""
+	}
+	if typ != podString {
+		r.err = fmt.Errorf("pipewire: expected string, got type %d", typ)
+		return ""
+	}
+	if n := len(body); n > 0 && body[n-1] == 0 {
+		body = body[:n-1]
+	}
+	return string(body)
+}
+
+// dict reads a spa_dict written as a Struct.
+func (r *podReader) dict() map[string]string {
+	typ, body := r.next()
+	if r.err != nil {
+		return nil
+	}
+	if typ != podStruct {
+		r.err = fmt.Errorf("pipewire: expected dict, got type %d", typ)
+		return nil
+	}
+
+	inner := &podReader{buf: body}
+	n := inner.int()
+	// The count comes from the peer; every item takes at least two 8 byte
+	// headers, so a larger count cannot be honest.
+	capacity := min(max(int(n), 0), len(inner.buf)/16)
+	items := make(map[string]string, capacity)
+	for i := int32(0); i < n && inner.err == nil; i++ {
+		k := inner.str()
+		v := inner.str()
+		items[k] = v
+	}
+	if inner.err != nil {
+		r.err = inner.err
+	}
+	return items
+}
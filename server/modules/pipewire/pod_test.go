@@ -0,0 +1,119 @@
+package pipewire
+
+import (
+	"bytes"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestPodRoundTrip(t *testing.T) {
+	props := map[string]string{
+		"application.name":              "Dynamic Island",
+		"pipewire.access.portal.app_id": "org.example.App",
+		"empty":                         "",
+	}
+	data := new(podWriter).int(-7).str("hello").str("").dict(props).int(42).structBytes()
+
+	r := newStructReader(data)
+	if got := r.int(); got != -7 {
+		t.Errorf("int = %d, want -7", got)
+	}
+	if got := r.str(); got != "hello" {
+		t.Errorf("str = %q, want %q", got, "hello")
+	}
+	if got := r.str(); got != "" {
+		t.Errorf("empty str = %q", got)
+	}
+	if got := r.dict(); !reflect.DeepEqual(got, props) {
+		t.Errorf("dict = %v, want %v", got, props)
+	}
+	if got := r.int(); got != 42 {
+		t.Errorf("int after dict = %d, want 42", got)
+	}
+	if r.err != nil {
+		t.Fatalf("unexpected error: %v", r.err)
+	}
+}
+
+func TestPodPadding(t *testing.T) {
+	tests := []struct {
+		name string
+		w    *podWriter
+		want []byte
+	}{
+		{
+			name: "int",
+			w:    new(podWriter).int(1),
+			want: []byte{4, 0, 0, 0, podInt, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "string",
+			w:    new(podWriter).str("abc"),
+			want: []byte{4, 0, 0, 0, podString, 0, 0, 0, 'a', 'b', 'c', 0, 0, 0, 0, 0},
+		},
+		{
+			name: "string filling the padding",
+			w:    new(podWriter).str("1234567"),
+			want: []byte{8, 0, 0, 0, podString, 0, 0, 0, '1', '2', '3', '4', '5', '6', '7', 0},
+		},
+	}
+	for _, tt := range tests {
+		if !bytes.Equal(tt.w.buf, tt.want) {
+			t.Errorf("%s: encoded % x, want % x", tt.name, tt.w.buf, tt.want)
+		}
+		if len(tt.w.buf)%8 != 0 {
+			t.Errorf("%s: length %d not 8 byte aligned", tt.name, len(tt.w.buf))
+		}
+	}
+}
+
+func TestPodReadNone(t *testing.T) {
+	// A None value (null string) has an empty body and no padding
+	inner := []byte{0, 0, 0, 0, podNone, 0, 0, 0}
+	inner = append(inner, new(podWriter).int(5).buf...)
+	data := new(podWriter).nest(&podWriter{buf: inner}).buf
+
+	r := newStructReader(data)
+	if got := r.str(); got != "" {
+		t.Errorf("None = %q, want empty", got)
+	}
+	if got := r.int(); got != 5 {
+		t.Errorf("int after None = %d, want 5", got)
+	}
+	if r.err != nil {
+		t.Fatalf("unexpected error: %v", r.err)
+	}
+}
+
+func TestPodTruncated(t *testing.T) {
+	data := new(podWriter).str("hello").structBytes()
+
+	for n := 0; n < len(data); n++ {
+		r := newStructReader(data[:n])
+		r.str()
+		if r.err == nil {
+			t.Errorf("no error reading %d of %d bytes", n, len(data))
+		}
+	}
+}
+
+func TestPodDictHugeCount(t *testing.T) {
+	// A count far beyond what the body can hold must fail without
+	// allocating for it
+	inner := new(podWriter).int(0x7FFFFFFF).str("key").str("value")
+	data := new(podWriter).nest(inner).structBytes()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	r := newStructReader(data)
+	r.dict()
+	runtime.ReadMemStats(&after)
+
+	if r.err == nil {
+		t.Error("expected an error for a count larger than the dict")
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("allocated %d bytes reading a 3 item dict", allocated)
+	}
+}
@@ -0,0 +1,364 @@
+package privacy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ledgerFileName = "privacy.jsonl"
+
+	// How long a stop waits for a start that was delivered after it.
+	earlyStopWindow = time.Minute
+)
+
+// Devices a usage session can be recorded for.
+const (
+	DeviceCamera     = "camera"
+	DeviceMicrophone = "microphone"
+	DeviceScreen     = "screen"
+)
+
+// Usage is one session of an app using a capture device. End is zero while
+// the session is still active.
+type Usage struct {
+	App   string `json:"app"`
+	PID   int    `json:"pid"`
+	AppID string `json:"appId,omitempty"`
+	// Device is camera, microphone or screen.
+	Device string `json:"device"`
+	// Detail names the camera, the microphone or what part of the screen
+	// was shared.
+	Detail   string `json:"detail,omitempty"`
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	Duration int64  `json:"duration"`
+}
+
+// AppUsage is the total use of capture devices by one app, in seconds.
+type AppUsage struct {
+	App        string `json:"app"`
+	AppID      string `json:"appId,omitempty"`
+	Camera     int64  `json:"camera"`
+	Microphone int64  `json:"microphone"`
+	Screen     int64  `json:"screen"`
+	Total      int64  `json:"total"`
+	Sessions   int    `json:"sessions"`
+	LastUsed   int64  `json:"lastUsed"`
+	Active     bool   `json:"active"`
+}
+
+// Ledger keeps the usage sessions of the retention period in memory and in
+// a JSON lines file. Sessions are written when they end.
+type Ledger struct {
+	path      string
+	enabled   bool
+	retention time.Duration
+
+	mu     sync.Mutex
+	usages []Usage
+	open   map[string]*Usage
+	// ended holds stops that arrived before their start; event handlers
+	// run concurrently.
+	ended map[string]time.Time
+}
+
+func NewLedger(dir string, enabled bool, retention time.Duration) *Ledger {
+	l := &Ledger{
+		enabled:   enabled,
+		retention: retention,
+		open:      make(map[string]*Usage),
+		ended:     make(map[string]time.Time),
+	}
+	if dir != "" {
+		l.path = filepath.Join(dir, ledgerFileName)
+	}
+	if enabled {
+		l.load()
+	}
+	return l
+}
+
+// Begin opens a session under key. A session already open under the key is
+// kept.
+func (l *Ledger) Begin(key string, usage Usage, at time.Time) {
+	if !l.enabled {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.expireEndedLocked(at)
+	if end, ok := l.ended[key]; ok {
+		delete(l.ended, key)
+		if !end.Before(at) {
+			usage.Start = at.Unix()
+			l.finishLocked(usage, end)
+			return
+		}
+	}
+	if _, ok := l.open[key]; ok {
+		return
+	}
+	usage.Start = at.Unix()
+	l.open[key] = &usage
+}
+
+// End closes the session under key.
+func (l *Ledger) End(key string, at time.Time) {
+	if !l.enabled {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	usage, ok := l.open[key]
+	if !ok {
+		l.expireEndedLocked(at)
+		l.ended[key] = at
+		return
+	}
+	delete(l.open, key)
+	l.finishLocked(*usage, at)
+}
+
+// EndAll closes every open session, e.g. on shutdown.
+func (l *Ledger) EndAll(at time.Time) {
+	if !l.enabled {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, usage := range l.open {
+		delete(l.open, key)
+		l.finishLocked(*usage, at)
+	}
+}
+
+// expireEndedLocked forgets stops that never met their start. Caller must
+// hold l.mu.
+func (l *Ledger) expireEndedLocked(now time.Time) {
+	for key, end := range l.ended {
+		if now.Sub(end) > earlyStopWindow {
+			delete(l.ended, key)
+		}
+	}
+}
+
+// finishLocked records a completed session. Caller must hold l.mu.
+func (l *Ledger) finishLocked(usage Usage, end time.Time) {
+	usage.End = end.Unix()
+	if usage.End < usage.Start {
+		usage.End = usage.Start
+	}
+	usage.Duration = usage.End - usage.Start
+	l.usages = append(l.usages, usage)
+
+	if err := l.appendToFile(usage); err != nil {
+		// log.Printf("⚠️ Privacy ledger: %v", err)
+	}
+}
+
+// History returns the sessions that were active at or after since (Unix
+// seconds), newest first. Active sessions come first with End zero.
+func (l *Ledger) History(since int64) []Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now().Unix()
+	history := make([]Usage, 0)
+	for _, usage := range l.open {
+		u := *usage
+		u.Duration = now - u.Start
+		history = append(history, u)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Start > history[j].Start
+	})
+
+	for i := len(l.usages) - 1; i >= 0; i-- {
+		if l.usages[i].End >= since {
+			history = append(history, l.usages[i])
+		}
+	}
+	return history
+}
+
+// Summary returns the totals per app over the retention period, most
+// recently used first.
+func (l *Ledger) Summary() []AppUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now().Unix()
+	apps := make(map[string]*AppUsage)
+	add := func(u Usage, active bool) {
+		name := u.AppID
+		if name == "" {
+			name = u.App
+		}
+		app := apps[name]
+		if app == nil {
+			app = &AppUsage{App: u.App, AppID: u.AppID}
+			apps[name] = app
+		}
+
+		switch u.Device {
+		case DeviceCamera:
+			app.Camera += u.Duration
+		case DeviceMicrophone:
+			app.Microphone += u.Duration
+		case DeviceScreen:
+			app.Screen += u.Duration
+		}
+		app.Total += u.Duration
+		app.Sessions++
+
+		lastUsed := u.End
+		if active {
+			lastUsed = now
+			app.Active = true
+		}
+		if lastUsed > app.LastUsed {
+			app.LastUsed = lastUsed
+		}
+	}
+
+	for _, u := range l.usages {
+		add(u, false)
+	}
+	for _, usage := range l.open {
+		u := *usage
+		u.Duration = now - u.Start
+		add(u, true)
+	}
+
+	summary := make([]AppUsage, 0, len(apps))
+	for _, app := range apps {
+		summary = append(summary, *app)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].LastUsed != summary[j].LastUsed {
+			return summary[i].LastUsed > summary[j].LastUsed
+		}
+		return summary[i].App < summary[j].App
+	})
+	return summary
+}
+
+// Prune drops sessions that ended before the retention period and rewrites
+// the file without them.
+func (l *Ledger) Prune() {
+	if !l.enabled || l.retention <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := time.Now().Add(-l.retention).Unix()
+	kept := l.usages[:0]
+	for _, u := range l.usages {
+		if u.End >= cutoff {
+			kept = append(kept, u)
+		}
+	}
+	if len(kept) == len(l.usages) {
+		return
+	}
+	l.usages = kept
+
+	if err := l.rewriteFile(); err != nil {
+		// log.Printf("⚠️ Privacy ledger: %v", err)
+	}
+}
+
+func (l *Ledger) appendToFile(usage Usage) error {
+	if l.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create ledger directory: %v", err)
+	}
+
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage: %v", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write ledger: %v", err)
+	}
+	return nil
+}
+
+// rewriteFile replaces the file with the sessions in memory. Caller must
+// hold l.mu.
+func (l *Ledger) rewriteFile() error {
+	if l.path == "" {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), ledgerFileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create ledger: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, u := range l.usages {
+		data, err := json.Marshal(u)
+		if err != nil {
+			continue
+		}
+		w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write ledger: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write ledger: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return fmt.Errorf("failed to replace ledger: %v", err)
+	}
+	return nil
+}
+
+func (l *Ledger) load() {
+	if l.path == "" {
+		return
+	}
+	f, err := os.Open(l.path)
+	if err != nil {
+		// log.Printf("⚠️ Privacy ledger: %v", err)
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var u Usage
+		if err := json.Unmarshal(scanner.Bytes(), &u); err != nil {
+			continue
+		}
+		l.usages = append(l.usages, u)
+	}
+}
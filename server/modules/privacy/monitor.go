@@ -0,0 +1,104 @@
+package privacy
+
+import (
+	"dynamic-island-server/core"
+	"fmt"
+	"time"
+)
+
+// PrivacySource records camera, microphone and screen capture sessions in
+// the ledger from the start and stop events of their sources.
+type PrivacySource struct {
+	ledger *Ledger
+}
+
+func NewPrivacySource(cfg core.PrivacyConfig) *PrivacySource {
+	retention := time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	return &PrivacySource{
+		ledger: NewLedger(core.DataDir(), cfg.RecordUsage, retention),
+	}
+}
+
+func (s *PrivacySource) GetName() string {
+	return "Privacy Ledger"
+}
+
+func (s *PrivacySource) Start(bus core.Bus, stopChan <-chan struct{}) error {
+	if !s.ledger.enabled {
+		return nil
+	}
+
+	bus.Subscribe(core.EventCameraStart, s)
+	bus.Subscribe(core.EventCameraStop, s)
+	bus.Subscribe(core.EventMicrophoneStart, s)
+	bus.Subscribe(core.EventMicrophoneStop, s)
+	bus.Subscribe(core.EventScreenShareStart, s)
+	bus.Subscribe(core.EventScreenShareStop, s)
+
+	s.ledger.Prune()
+
+	ticker := time.NewTicker(time.Hour)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.ledger.Prune()
+			case <-stopChan:
+				s.ledger.EndAll(time.Now())
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *PrivacySource) Handle(event *core.Event) error {
+	var device, detail string
+	start := false
+
+	switch event.Type {
+	case core.EventCameraStart, core.EventCameraStop:
+		device = DeviceCamera
+		detail, _ = event.Metadata["camera"].(string)
+		start = event.Type == core.EventCameraStart
+	case core.EventMicrophoneStart, core.EventMicrophoneStop:
+		device = DeviceMicrophone
+		detail, _ = event.Metadata["description"].(string)
+		start = event.Type == core.EventMicrophoneStart
+	case core.EventScreenShareStart, core.EventScreenShareStop:
+		device = DeviceScreen
+		detail, _ = event.Metadata["source_type"].(string)
+		start = event.Type == core.EventScreenShareStart
+	default:
+		return nil
+	}
+
+	key := fmt.Sprintf("%s:%s:%d", device, event.AppName, event.PID)
+	if !start {
+		s.ledger.End(key, event.Timestamp)
+		return nil
+	}
+
+	appID, _ := event.Metadata["app_id"].(string)
+	s.ledger.Begin(key, Usage{
+		App:    event.AppName,
+		PID:    event.PID,
+		AppID:  appID,
+		Device: device,
+		Detail: detail,
+	}, event.Timestamp)
+	return nil
+}
+
+// GetHistory returns the sessions active since the given Unix time, newest
+// first.
+func (s *PrivacySource) GetHistory(since int64) []Usage {
+	return s.ledger.History(since)
+}
+
+// GetSummary returns the usage totals per app.
+func (s *PrivacySource) GetSummary() []AppUsage {
+	return s.ledger.Summary()
+}
@@ -0,0 +1,34 @@
+package privacy
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type PrivacyService struct {
+	source *PrivacySource
+}
+
+func NewPrivacyService(source *PrivacySource) *PrivacyService {
+	return &PrivacyService{source: source}
+}
+
+// GetPrivacyHistory returns the capture sessions active since the given
+// Unix time as JSON, newest first. Zero returns the whole retention period.
+func (s *PrivacyService) GetPrivacyHistory(since int64) (string, error) {
+	data, err := json.Marshal(s.source.GetHistory(since))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode history: %v", err)
+	}
+	return string(data), nil
+}
+
+// GetPrivacySummary returns the capture time per app and device as JSON,
+// most recently used first.
+func (s *PrivacyService) GetPrivacySummary() (string, error) {
+	data, err := json.Marshal(s.source.GetSummary())
+	if err != nil {
+		return "", fmt.Errorf("failed to encode summary: %v", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,59 @@
+// Package procinfo names processes from /proc.
+package procinfo
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Name prefers the executable name from the command line over comm, which
+// the kernel truncates to 15 characters and some apps rename. It returns ""
+// if the process is gone.
+func Name(pid int) string {
+	base := "/proc/" + strconv.Itoa(pid)
+
+	comm, err := os.ReadFile(base + "/comm")
+	if err != nil {
+		return ""
+	}
+	name := strings.TrimSpace(string(comm))
+
+	cmdline, err := os.ReadFile(base + "/cmdline")
+	if err != nil {
+		return name
+	}
+	argv0, _, _ := strings.Cut(string(cmdline), "\x00")
+	if fields := strings.Fields(argv0); len(fields) > 0 {
+		if exe := filepath.Base(fields[0]); exe != "" && exe != "." && exe != "/" {
+			name = exe
+		}
+	}
+	return name
+}
+
+// FlatpakAppID reads the app ID of a sandboxed process from the
+// .flatpak-info file at the root of its sandbox.
+func FlatpakAppID(pid int) string {
+	f, err := os.Open("/proc/" + strconv.Itoa(pid) + "/root/.flatpak-info")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = line
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && section == "[Application]" && strings.TrimSpace(key) == "name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,21 @@
+package screenshare
+
+import "dynamic-island-server/modules/procinfo"
+
+// appIdentity names the process behind a bus caller and, for sandboxed
+// apps, its flatpak app ID.
+func appIdentity(pid int) (name, appID string) {
+	if pid <= 0 {
+		return "unknown", ""
+	}
+
+	name = procinfo.Name(pid)
+	appID = procinfo.FlatpakAppID(pid)
+	if name == "" {
+		name = appID
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	return name, appID
+}
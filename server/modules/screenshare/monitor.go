@@ -0,0 +1,458 @@
+package screenshare
+
+import (
+	"dynamic-island-server/core"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	mutterSessionIntf = "org.gnome.Mutter.ScreenCast.Session"
+	shellScreencast   = "org.gnome.Shell.Screencast"
+	portalScreenCast  = "org.freedesktop.portal.ScreenCast"
+	portalSession     = "org.freedesktop.portal.Session"
+	portalRequest     = "org.freedesktop.portal.Request"
+	portalRequestPath = "/org/freedesktop/portal/desktop/request/"
+
+	// Processes that call Mutter on behalf of others; their sessions are
+	// reported at the portal or Shell level instead.
+	portalBackendName = "org.freedesktop.impl.portal.desktop.gnome"
+)
+
+// Portal source types.
+const (
+	portalSourceMonitor = 1
+	portalSourceWindow  = 2
+	portalSourceVirtual = 4
+)
+
+// What is being shared.
+const (
+	SourceMonitor = "monitor"
+	SourceWindow  = "window"
+	SourceArea    = "area"
+	SourceVirtual = "virtual"
+)
+
+// shareSession is a screen capture session and the app that asked for it.
+type shareSession struct {
+	// owner is the unique bus name of the caller.
+	owner   string
+	via     string
+	source  string
+	streams int
+	started bool
+
+	app   string
+	pid   int
+	appID string
+}
+
+// ScreenShareSource follows screen capture sessions by monitoring the
+// session bus: xdg-desktop-portal ScreenCast requests from apps, Mutter's
+// ScreenCast API used directly, and GNOME Shell's screen recorder.
+type ScreenShareSource struct {
+	conn    *dbus.Conn
+	monitor *dbus.Conn
+
+	mu sync.Mutex
+	// sessions are keyed by session object path; Shell recordings by
+	// "shell:" plus the caller.
+	sessions map[string]*shareSession
+	// pendingStarts holds, per portal caller, the sessions it asked to
+	// start until the portal responds.
+	pendingStarts map[string][]pendingStart
+}
+
+// pendingStart is a portal Start call waiting for its Response.
+type pendingStart struct {
+	// token is the handle_token of the request, the last element of its
+	// object path. Empty if the caller let the portal pick one.
+	token   string
+	session string
+}
+
+func NewScreenShareSource() *ScreenShareSource {
+	return &ScreenShareSource{
+		sessions:      make(map[string]*shareSession),
+		pendingStarts: make(map[string][]pendingStart),
+	}
+}
+
+func (s *ScreenShareSource) GetName() string {
+	return "Screen Share Monitor"
+}
+
+func (s *ScreenShareSource) Start(bus core.Bus, stopChan <-chan struct{}) error {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return fmt.Errorf("failed to connect to session bus: %v", err)
+	}
+	s.conn = conn
+
+	// A monitor connection sees calls between other peers but can no
+	// longer send, so it is separate from the one used for lookups.
+	monitor, err := dbus.SessionBusPrivate()
+	if err != nil {
+		return fmt.Errorf("failed to connect to session bus: %v", err)
+	}
+	if err := monitor.Auth(nil); err != nil {
+		monitor.Close()
+		return fmt.Errorf("failed to authenticate monitor: %v", err)
+	}
+	if err := monitor.Hello(); err != nil {
+		monitor.Close()
+		return fmt.Errorf("failed to register monitor: %v", err)
+	}
+
+	rules := []string{
+		"type='method_call',interface='" + mutterSessionIntf + "'",
+		"type='signal',interface='" + mutterSessionIntf + "',member='Closed'",
+		"type='method_call',interface='" + shellScreencast + "'",
+		"type='method_call',interface='" + portalScreenCast + "'",
+		"type='method_call',interface='" + portalSession + "',member='Close'",
+		"type='signal',interface='" + portalSession + "',member='Closed'",
+		"type='signal',interface='" + portalRequest + "',member='Response'",
+		"type='signal',sender='org.freedesktop.DBus',member='NameOwnerChanged'",
+	}
+	call := monitor.BusObject().Call("org.freedesktop.DBus.Monitoring.BecomeMonitor", 0, rules, uint32(0))
+	if call.Err != nil {
+		monitor.Close()
+		return fmt.Errorf("failed to become monitor: %v", call.Err)
+	}
+	s.monitor = monitor
+
+	messages := make(chan *dbus.Message, 64)
+	monitor.Eavesdrop(messages)
+
+	go func() {
+		defer monitor.Close()
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				s.handleMessage(bus, msg)
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *ScreenShareSource) handleMessage(bus core.Bus, msg *dbus.Message) {
+	iface, _ := msg.Headers[dbus.FieldInterface].Value().(string)
+	member, _ := msg.Headers[dbus.FieldMember].Value().(string)
+	sender, _ := msg.Headers[dbus.FieldSender].Value().(string)
+	path, _ := msg.Headers[dbus.FieldPath].Value().(dbus.ObjectPath)
+
+	switch msg.Type {
+	case dbus.TypeMethodCall:
+		switch iface {
+		case mutterSessionIntf:
+			s.handleMutterCall(bus, string(path), sender, member)
+		case shellScreencast:
+			s.handleShellCall(bus, sender, member)
+		case portalScreenCast:
+			s.handlePortalCall(sender, member, msg.Body)
+		case portalSession:
+			s.stopSession(bus, string(path))
+		}
+
+	case dbus.TypeSignal:
+		switch {
+		case iface == mutterSessionIntf || iface == portalSession:
+			// Closed
+			s.stopSession(bus, string(path))
+		case iface == portalRequest:
+			s.handlePortalResponse(bus, string(path), msg.Body)
+		case member == "NameOwnerChanged" && len(msg.Body) == 3:
+			name, _ := msg.Body[0].(string)
+			newOwner, _ := msg.Body[2].(string)
+			if strings.HasPrefix(name, ":") && newOwner == "" {
+				s.stopOwner(bus, name)
+			}
+		}
+	}
+}
+
+func (s *ScreenShareSource) handleMutterCall(bus core.Bus, path, sender, member string) {
+	s.mu.Lock()
+	session := s.sessions[path]
+	if session == nil {
+		session = &shareSession{owner: sender, via: "mutter"}
+		s.sessions[path] = session
+	}
+
+	switch member {
+	case "RecordMonitor":
+		session.source = SourceMonitor
+		session.streams++
+	case "RecordWindow":
+		session.source = SourceWindow
+		session.streams++
+	case "RecordArea":
+		session.source = SourceArea
+		session.streams++
+	case "RecordVirtual":
+		session.source = SourceVirtual
+		session.streams++
+	case "Start":
+		s.mu.Unlock()
+		if s.isDelegate(sender) {
+			// Reported by the portal or Shell call that caused it
+			s.mu.Lock()
+			delete(s.sessions, path)
+			s.mu.Unlock()
+			return
+		}
+		s.startSession(bus, path)
+		return
+	case "Stop":
+		s.mu.Unlock()
+		s.stopSession(bus, path)
+		return
+	}
+	s.mu.Unlock()
+}
+
+func (s *ScreenShareSource) handleShellCall(bus core.Bus, sender, member string) {
+	key := "shell:" + sender
+
+	switch member {
+	case "Screencast", "ScreencastArea":
+		source := SourceMonitor
+		if member == "ScreencastArea" {
+			source = SourceArea
+		}
+		s.mu.Lock()
+		s.sessions[key] = &shareSession{owner: sender, via: "shell", source: source, streams: 1}
+		s.mu.Unlock()
+		s.startSession(bus, key)
+	case "StopScreencast":
+		s.stopSession(bus, key)
+	}
+}
+
+func (s *ScreenShareSource) handlePortalCall(sender, member string, body []interface{}) {
+	if len(body) < 2 {
+		return
+	}
+	session, ok := body[0].(dbus.ObjectPath)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch member {
+	case "SelectSources":
+		shared := &shareSession{owner: sender, via: "portal"}
+		if options, ok := body[1].(map[string]dbus.Variant); ok {
+			if types, ok := options["types"].Value().(uint32); ok {
+				shared.source = portalSourceName(types)
+			}
+		}
+		s.sessions[string(session)] = shared
+	case "Start":
+		if _, ok := s.sessions[string(session)]; !ok {
+			s.sessions[string(session)] = &shareSession{owner: sender, via: "portal"}
+		}
+		var token string
+		if len(body) >= 3 {
+			if options, ok := body[2].(map[string]dbus.Variant); ok {
+				token, _ = options["handle_token"].Value().(string)
+			}
+		}
+		s.pendingStarts[sender] = append(s.pendingStarts[sender], pendingStart{token: token, session: string(session)})
+	}
+}
+
+// takePendingLocked removes and returns the session of the caller's Start
+// request with the given token. Requests without a handle_token cannot be
+// told apart, so they are answered in call order. Caller must hold s.mu.
+func (s *ScreenShareSource) takePendingLocked(caller, token string) (string, bool) {
+	pending := s.pendingStarts[caller]
+	match := -1
+	for i, p := range pending {
+		if p.token == token {
+			match = i
+			break
+		}
+		if match < 0 && p.token == "" {
+			match = i
+		}
+	}
+	if match < 0 {
+		return "", false
+	}
+
+	key := pending[match].session
+	pending = append(pending[:match], pending[match+1:]...)
+	if len(pending) == 0 {
+		delete(s.pendingStarts, caller)
+	} else {
+		s.pendingStarts[caller] = pending
+	}
+	return key, true
+}
+
+// handlePortalResponse starts the session whose Start request succeeded.
+// Requests live at .../request/<caller with '.' as '_'>/<token>.
+func (s *ScreenShareSource) handlePortalResponse(bus core.Bus, path string, body []interface{}) {
+	rest, ok := strings.CutPrefix(path, portalRequestPath)
+	if !ok || len(body) < 2 {
+		return
+	}
+	escaped, token, _ := strings.Cut(rest, "/")
+	caller := ":" + strings.ReplaceAll(escaped, "_", ".")
+
+	results, _ := body[1].(map[string]dbus.Variant)
+	streams, hasStreams := results["streams"]
+
+	s.mu.Lock()
+	if code, _ := body[0].(uint32); code != 0 {
+		// Cancelled in the dialog or failed; the reply carries no streams
+		if key, ok := s.takePendingLocked(caller, token); ok {
+			if session := s.sessions[key]; session != nil && !session.started {
+				delete(s.sessions, key)
+			}
+		}
+		s.mu.Unlock()
+		return
+	}
+	if !hasStreams {
+		// Response to CreateSession or SelectSources
+		s.mu.Unlock()
+		return
+	}
+
+	key, ok := s.takePendingLocked(caller, token)
+	session := s.sessions[key]
+	if !ok || session == nil {
+		s.mu.Unlock()
+		return
+	}
+
+	if list, ok := streams.Value().([][]interface{}); ok {
+		session.streams = len(list)
+		for _, stream := range list {
+			if len(stream) < 2 {
+				continue
+			}
+			props, _ := stream[1].(map[string]dbus.Variant)
+			if types, ok := props["source_type"].Value().(uint32); ok {
+				session.source = portalSourceName(types)
+				break
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	s.startSession(bus, key)
+}
+
+func portalSourceName(types uint32) string {
+	switch types {
+	case portalSourceMonitor:
+		return SourceMonitor
+	case portalSourceWindow:
+		return SourceWindow
+	case portalSourceVirtual:
+		return SourceVirtual
+	}
+	return ""
+}
+
+// isDelegate reports whether sender calls Mutter on behalf of another app.
+func (s *ScreenShareSource) isDelegate(sender string) bool {
+	for _, name := range []string{portalBackendName, shellScreencast} {
+		var owner string
+		if err := s.conn.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, name).Store(&owner); err == nil && owner == sender {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ScreenShareSource) startSession(bus core.Bus, key string) {
+	s.mu.Lock()
+	session := s.sessions[key]
+	if session == nil || session.started {
+		s.mu.Unlock()
+		return
+	}
+	session.started = true
+	owner := session.owner
+	s.mu.Unlock()
+
+	pid := s.callerPID(owner)
+	app, appID := appIdentity(pid)
+
+	s.mu.Lock()
+	session.pid, session.app, session.appID = pid, app, appID
+	shared := *session
+	s.mu.Unlock()
+
+	event := core.NewEvent(core.EventScreenShareStart, shared.app, shared.pid)
+	event.Metadata["device"] = "screen"
+	event.Metadata["source_type"] = shared.source
+	event.Metadata["via"] = shared.via
+	if shared.streams > 1 {
+		event.Metadata["streams"] = shared.streams
+	}
+	if shared.appID != "" {
+		event.Metadata["app_id"] = shared.appID
+	}
+	bus.Publish(event)
+}
+
+func (s *ScreenShareSource) stopSession(bus core.Bus, key string) {
+	s.mu.Lock()
+	session := s.sessions[key]
+	delete(s.sessions, key)
+	s.mu.Unlock()
+
+	if session == nil || !session.started {
+		return
+	}
+
+	event := core.NewEvent(core.EventScreenShareStop, session.app, session.pid)
+	event.Metadata["device"] = "screen"
+	event.Metadata["source_type"] = session.source
+	event.Metadata["via"] = session.via
+	bus.Publish(event)
+}
+
+// stopOwner ends every session of a caller that left the bus.
+func (s *ScreenShareSource) stopOwner(bus core.Bus, owner string) {
+	s.mu.Lock()
+	delete(s.pendingStarts, owner)
+	var keys []string
+	for key, session := range s.sessions {
+		if session.owner == owner {
+			keys = append(keys, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, key := range keys {
+		s.stopSession(bus, key)
+	}
+}
+
+func (s *ScreenShareSource) callerPID(owner string) int {
+	var pid uint32
+	call := s.conn.BusObject().Call("org.freedesktop.DBus.GetConnectionUnixProcessID", 0, owner)
+	if call.Err != nil || call.Store(&pid) != nil {
+		return 0
+	}
+	return int(pid)
+}
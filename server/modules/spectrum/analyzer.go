@@ -0,0 +1,117 @@
+package spectrum
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+const (
+	analysisRate = 16000
+	fftSize      = 1024
+
+	minFrequency = 50.0
+	maxFrequency = 8000.0
+
+	// Band levels are mapped from [floorDB, 0] dBFS to [0, 1].
+	floorDB = -70.0
+	// decay is how much of the previous value a falling band keeps per frame.
+	decay = 0.8
+)
+
+// analyzer turns a mono sample stream into log-spaced band levels.
+type analyzer struct {
+	window []float64
+	buf    []float64
+	pos    int
+	filled bool
+
+	edges  []int
+	levels []float64
+	work   []complex128
+}
+
+func newAnalyzer(bands int) *analyzer {
+	a := &analyzer{
+		window: hannWindow(fftSize),
+		buf:    make([]float64, fftSize),
+		levels: make([]float64, bands),
+		work:   make([]complex128, fftSize),
+	}
+
+	// Bin boundaries of bands spaced evenly on a log scale; every band gets
+	// at least one bin.
+	binHz := float64(analysisRate) / fftSize
+	a.edges = make([]int, bands+1)
+	for i := 0; i <= bands; i++ {
+		f := minFrequency * math.Pow(maxFrequency/minFrequency, float64(i)/float64(bands))
+		a.edges[i] = int(math.Round(f / binHz))
+		if i > 0 && a.edges[i] <= a.edges[i-1] {
+			a.edges[i] = a.edges[i-1] + 1
+		}
+	}
+	return a
+}
+
+// write appends samples to the ring buffer.
+func (a *analyzer) write(samples []float32) {
+	for _, v := range samples {
+		a.buf[a.pos] = float64(v)
+		a.pos++
+		if a.pos == fftSize {
+			a.pos = 0
+			a.filled = true
+		}
+	}
+}
+
+// bands analyses the latest fftSize samples and returns the smoothed levels.
+func (a *analyzer) bands() []float64 {
+	if !a.filled {
+		return nil
+	}
+
+	for i := 0; i < fftSize; i++ {
+		sample := a.buf[(a.pos+i)%fftSize]
+		a.work[i] = complex(sample*a.window[i], 0)
+	}
+	fft(a.work)
+
+	out := make([]float64, len(a.levels))
+	for b := range a.levels {
+		lo, hi := a.edges[b], a.edges[b+1]
+		if hi > fftSize/2 {
+			hi = fftSize / 2
+		}
+		var peak float64
+		for k := lo; k < hi; k++ {
+			if m := cmplx.Abs(a.work[k]); m > peak {
+				peak = m
+			}
+		}
+
+		// A full-scale sine peaks at fftSize/4 with a Hann window.
+		level := 0.0
+		if amplitude := peak * 4 / fftSize; amplitude > 0 {
+			level = (20*math.Log10(amplitude) - floorDB) / -floorDB
+		}
+		level = math.Max(0, math.Min(1, level))
+
+		if fallen := a.levels[b] * decay; level < fallen {
+			level = fallen
+		}
+		a.levels[b] = level
+		out[b] = math.Round(level*1000) / 1000
+	}
+	return out
+}
+
+func (a *analyzer) reset() {
+	for i := range a.buf {
+		a.buf[i] = 0
+	}
+	for i := range a.levels {
+		a.levels[i] = 0
+	}
+	a.pos = 0
+	a.filled = false
+}
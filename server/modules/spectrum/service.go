@@ -0,0 +1,15 @@
+package spectrum
+
+type SpectrumService struct {
+	source *SpectrumSource
+}
+
+func NewSpectrumService(source *SpectrumSource) *SpectrumService {
+	return &SpectrumService{source: source}
+}
+
+// SetSpectrumActive tells the server whether a visualizer is on screen.
+// Spectrum frames are only sent while one is and media is playing.
+func (s *SpectrumService) SetSpectrumActive(active bool) {
+	s.source.SetActive(active)
+}
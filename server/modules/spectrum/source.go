@@ -0,0 +1,193 @@
+package spectrum
+
+import (
+	"dynamic-island-server/core"
+	"dynamic-island-server/modules/audio"
+	"sync"
+	"time"
+)
+
+const maxFrameRate = 60
+
+// SpectrumSource analyses what the default output is playing and hands the
+// band levels to emit, which sends them as the Spectrum D-Bus signal. Frames
+// bypass the event bus so the middleware and EventOccurred listeners never
+// see them.
+//
+// It only records while media is Playing and a client has asked for frames
+// with SetActive (the island shows the visualizer).
+type SpectrumSource struct {
+	emit        func(bands []float64)
+	backend     *audio.Backend
+	bands       int
+	fps         int
+	enabled     bool
+	frameLength time.Duration
+
+	mu        sync.Mutex
+	playing   bool
+	active    bool
+	lastMedia time.Time
+
+	// streamMu serializes update and guards the stream. It is held across
+	// backend requests, so the sample callback must never take it.
+	streamMu sync.Mutex
+	stream   *audio.RecordStream
+	monitor  string
+
+	frameMu   sync.Mutex
+	analyzer  *analyzer
+	lastFrame time.Time
+}
+
+func NewSpectrumSource(emit func(bands []float64), backend *audio.Backend, cfg core.SpectrumConfig) *SpectrumSource {
+	fps := cfg.FrameRate
+	if fps <= 0 {
+		fps = 25
+	}
+	if fps > maxFrameRate {
+		fps = maxFrameRate
+	}
+	bands := cfg.Bands
+	if bands <= 0 {
+		bands = 6
+	}
+	if bands > 64 {
+		bands = 64
+	}
+
+	return &SpectrumSource{
+		emit:        emit,
+		backend:     backend,
+		bands:       bands,
+		fps:         fps,
+		enabled:     cfg.Enabled,
+		analyzer:    newAnalyzer(bands),
+		frameLength: time.Second / time.Duration(fps),
+	}
+}
+
+func (s *SpectrumSource) GetName() string {
+	return "Spectrum Analyzer"
+}
+
+func (s *SpectrumSource) Start(bus core.Bus, stopChan <-chan struct{}) error {
+	if !s.enabled {
+		return nil
+	}
+
+	bus.Subscribe(core.EventMediaChanged, s)
+
+	// Follow default sink switches while running
+	unsubscribe := s.backend.Subscribe(audio.MaskServer, func(ev audio.SubscriptionEvent) {
+		s.update()
+	})
+
+	go func() {
+		<-stopChan
+		unsubscribe()
+		s.SetActive(false)
+	}()
+
+	return nil
+}
+
+// Handle tracks the playback status from media_changed events. Handlers run
+// concurrently, so events older than the last one seen are ignored.
+func (s *SpectrumSource) Handle(event *core.Event) error {
+	status, _ := event.Metadata["status"].(string)
+
+	s.mu.Lock()
+	if event.Timestamp.Before(s.lastMedia) {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastMedia = event.Timestamp
+	s.playing = status == "Playing"
+	s.mu.Unlock()
+
+	s.update()
+	return nil
+}
+
+// SetActive is called by the client when the visualizer becomes visible or
+// hidden.
+func (s *SpectrumSource) SetActive(active bool) {
+	s.mu.Lock()
+	s.active = active
+	s.mu.Unlock()
+
+	s.update()
+}
+
+// update opens or closes the monitor stream to match the current state.
+func (s *SpectrumSource) update() {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+
+	s.mu.Lock()
+	want := s.enabled && s.active && s.playing
+	s.mu.Unlock()
+
+	monitor := ""
+	if want {
+		if sink, err := s.backend.Sink(audio.DefaultSink); err == nil {
+			monitor = sink.MonitorSourceName
+		}
+	}
+
+	if s.stream != nil && (!want || monitor != s.monitor) {
+		s.stream.Close()
+		s.stream = nil
+		// Let the bars fall instead of freezing on the last frame
+		s.emit(make([]float64, s.bands))
+	}
+	if !want || monitor == "" || s.stream != nil {
+		return
+	}
+
+	s.frameMu.Lock()
+	s.analyzer.reset()
+	s.frameMu.Unlock()
+
+	var stream *audio.RecordStream
+	stream, err := s.backend.Record(audio.RecordOptions{
+		Source: monitor,
+		Rate:   analysisRate,
+		// About one packet per frame keeps wakeups to the frame rate
+		FragmentSize: uint32(4 * analysisRate / s.fps),
+		Hidden:       true,
+		Props:        map[string]string{"media.name": "Spectrum"},
+	}, s.onSamples, func() {
+		// onEnd may run inside Record, so forget the stream asynchronously
+		go func() {
+			s.streamMu.Lock()
+			if s.stream == stream {
+				s.stream = nil
+			}
+			s.streamMu.Unlock()
+		}()
+	})
+	if err != nil {
+		return
+	}
+	s.stream = stream
+	s.monitor = monitor
+}
+
+// onSamples runs on the audio connection's reader goroutine.
+func (s *SpectrumSource) onSamples(samples []float32) {
+	s.frameMu.Lock()
+	s.analyzer.write(samples)
+	if time.Since(s.lastFrame) < s.frameLength {
+		s.frameMu.Unlock()
+		return
+	}
+	s.lastFrame = time.Now()
+	bands := s.analyzer.bands()
+	s.frameMu.Unlock()
+
+	if bands != nil {
+		s.emit(bands)
+	}
+}
@@ -0,0 +1,212 @@
+package volume
+
+import (
+	"dynamic-island-server/modules/audio"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type AudioDevice struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	FormFactor  string `json:"formFactor"`
+	Icon        string `json:"icon"`
+	Available   bool   `json:"available"`
+	Default     bool   `json:"default"`
+	Level       int    `json:"level"`
+	Muted       bool   `json:"muted"`
+}
+
+const (
+	FormFactorMicrophone = "microphone"
+	FormFactorHeadset    = "headset"
+	FormFactorWebcam     = "webcam"
+)
+
+// inputFormFactor classifies a source the same way formFactor does sinks.
+func inputFormFactor(source *audio.Source) string {
+	props := source.Props
+	if props["device.bus"] == "bluetooth" || props["device.api"] == "bluez5" ||
+		strings.HasPrefix(source.Name, "bluez_") {
+		return FormFactorBluetooth
+	}
+
+	port := strings.ToLower(source.ActivePort)
+	if strings.Contains(port, "headset") || strings.Contains(port, "headphone") {
+		return FormFactorHeadset
+	}
+
+	switch props["device.form_factor"] {
+	case "webcam":
+		return FormFactorWebcam
+	case "headset", "headphone", "hands-free":
+		return FormFactorHeadset
+	}
+	return FormFactorMicrophone
+}
+
+func inputIcon(formFactor string) string {
+	switch formFactor {
+	case FormFactorBluetooth:
+		return "bluetooth-active-symbolic"
+	case FormFactorHeadset:
+		return "audio-headset-symbolic"
+	case FormFactorWebcam:
+		return "camera-web-symbolic"
+	default:
+		return "audio-input-microphone-symbolic"
+	}
+}
+
+// portDescription returns the active port's label when there is a choice
+// of ports, otherwise the device description.
+func portDescription(ports []audio.Port, active, fallback string) string {
+	if len(ports) > 1 {
+		for _, p := range ports {
+			if p.Name == active && p.Description != "" {
+				return p.Description
+			}
+		}
+	}
+	return fallback
+}
+
+// ListAudioOutputs returns every sink as JSON, marking the default one.
+func (s *VolumeService) ListAudioOutputs() (string, error) {
+	info, err := s.backend.ServerInfo()
+	if err != nil {
+		return "", fmt.Errorf("failed to get server info: %v", err)
+	}
+	sinks, err := s.backend.Sinks()
+	if err != nil {
+		return "", fmt.Errorf("failed to list outputs: %v", err)
+	}
+
+	devices := make([]AudioDevice, 0, len(sinks))
+	for i := range sinks {
+		sink := &sinks[i]
+		ff := formFactor(sink)
+		devices = append(devices, AudioDevice{
+			Name:        sink.Name,
+			Description: outputDescription(sink),
+			FormFactor:  ff,
+			Icon:        outputIcon(ff),
+			Available:   sink.Available(),
+			Default:     sink.Name == info.DefaultSink,
+			Level:       sink.Level(),
+			Muted:       sink.Muted,
+		})
+	}
+
+	data, err := json.Marshal(devices)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode outputs: %v", err)
+	}
+	return string(data), nil
+}
+
+// ListAudioInputs returns every capture source as JSON. Monitors of sinks
+// are left out since they are not microphones.
+func (s *VolumeService) ListAudioInputs() (string, error) {
+	info, err := s.backend.ServerInfo()
+	if err != nil {
+		return "", fmt.Errorf("failed to get server info: %v", err)
+	}
+	sources, err := s.backend.Sources()
+	if err != nil {
+		return "", fmt.Errorf("failed to list inputs: %v", err)
+	}
+
+	devices := make([]AudioDevice, 0, len(sources))
+	for i := range sources {
+		source := &sources[i]
+		if source.IsMonitor() {
+			continue
+		}
+		ff := inputFormFactor(source)
+		devices = append(devices, AudioDevice{
+			Name:        source.Name,
+			Description: portDescription(source.Ports, source.ActivePort, source.Description),
+			FormFactor:  ff,
+			Icon:        inputIcon(ff),
+			Available:   source.Available(),
+			Default:     source.Name == info.DefaultSource,
+			Level:       source.Level(),
+			Muted:       source.Muted,
+		})
+	}
+
+	data, err := json.Marshal(devices)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode inputs: %v", err)
+	}
+	return string(data), nil
+}
+
+// SetDefaultAudioOutput makes name the default sink and, if moveStreams is
+// set, moves the streams currently playing elsewhere onto it.
+func (s *VolumeService) SetDefaultAudioOutput(name string, moveStreams bool) error {
+	sink, err := s.backend.Sink(name)
+	if err != nil {
+		return fmt.Errorf("unknown output %q: %v", name, err)
+	}
+	if err := s.backend.SetDefaultSink(sink.Name); err != nil {
+		return fmt.Errorf("failed to set default output: %v", err)
+	}
+	if !moveStreams {
+		return nil
+	}
+
+	inputs, err := s.backend.SinkInputs()
+	if err != nil {
+		return fmt.Errorf("failed to list streams: %v", err)
+	}
+	for _, input := range inputs {
+		if input.Sink != sink.Index {
+			// Streams pinned with dont_move refuse; leave them where they are
+			s.backend.MoveSinkInput(input.Index, sink.Name)
+		}
+	}
+	return nil
+}
+
+// SetDefaultAudioInput makes name the default source and, if moveStreams is
+// set, moves the active recording streams onto it.
+func (s *VolumeService) SetDefaultAudioInput(name string, moveStreams bool) error {
+	source, err := s.backend.Source(name)
+	if err != nil {
+		return fmt.Errorf("unknown input %q: %v", name, err)
+	}
+	if source.IsMonitor() {
+		return fmt.Errorf("%q is a monitor, not an input", name)
+	}
+	if err := s.backend.SetDefaultSource(source.Name); err != nil {
+		return fmt.Errorf("failed to set default input: %v", err)
+	}
+	if !moveStreams {
+		return nil
+	}
+
+	outputs, err := s.backend.SourceOutputs()
+	if err != nil {
+		return fmt.Errorf("failed to list streams: %v", err)
+	}
+	sources, err := s.backend.Sources()
+	if err != nil {
+		return fmt.Errorf("failed to list inputs: %v", err)
+	}
+	monitors := make(map[uint32]bool)
+	for _, src := range sources {
+		if src.IsMonitor() {
+			monitors[src.Index] = true
+		}
+	}
+	for _, output := range outputs {
+		// Recorders of a sink monitor (visualizers, screen recorders) stay put
+		if output.Source != source.Index && !monitors[output.Source] {
+			s.backend.MoveSourceOutput(output.Index, source.Name)
+		}
+	}
+	return nil
+}
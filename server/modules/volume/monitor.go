@@ -1,14 +1,9 @@
 package volume
 
 import (
-	"bufio"
 	"dynamic-island-server/core"
-	"os/exec"
-	"regexp"
-	"strconv"
-	"strings"
+	"dynamic-island-server/modules/audio"
 	"sync"
-	"time"
 )
 
 type VolumeSource struct {
@@ -17,16 +12,20 @@ type VolumeSource struct {
 	lastLevel   int
 	lastMuted   bool
 	lastSink    string
+	lastPort    string
 	initialized bool
 	stopOnce    sync.Once
-	volumeRegex *regexp.Regexp
+	backend     *audio.Backend
+
+	streamsMu sync.Mutex
+	streams   map[uint32]AudioStream
 }
 
-func NewVolumeSource() *VolumeSource {
+func NewVolumeSource(backend *audio.Backend) *VolumeSource {
 	return &VolumeSource{
 		stopChan:    make(chan struct{}),
 		initialized: false,
-		volumeRegex: regexp.MustCompile(`(\d+)%`),
+		backend:     backend,
 	}
 }
 
@@ -38,55 +37,28 @@ func (s *VolumeSource) Start(bus core.Bus, stopChan <-chan struct{}) error {
 	// log.Println("🔊 Volume Monitor started (PulseAudio)")
 
 	s.fetchAndPublish(bus)
+	s.syncStreams(bus)
 
-	go func() {
-
-		cmd := exec.Command("sh", "-c", "LANG=C pactl subscribe")
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			// log.Printf("⚠️ Error creating stdout pipe for pactl: %v", err)
+	// Default sink switches arrive as server changes
+	unsubscribe := s.backend.Subscribe(audio.MaskSink|audio.MaskServer, func(ev audio.SubscriptionEvent) {
+		if ev.Facility == audio.FacilitySink && ev.Type == audio.EventRemove {
 			return
 		}
+		s.fetchAndPublish(bus)
+	})
+	unsubscribeStreams := s.backend.Subscribe(audio.MaskSinkInput, func(ev audio.SubscriptionEvent) {
+		s.syncStreams(bus)
+	})
 
-		if err := cmd.Start(); err != nil {
-			// log.Printf("⚠️ Error starting pactl subscribe: %v", err)
-			return
-		}
-
-		defer func() {
-			if cmd.Process != nil {
-				cmd.Process.Kill()
-				cmd.Wait()
-			}
-		}()
-
-		scanner := bufio.NewScanner(stdout)
-
-		go func() {
-			select {
-			case <-stopChan:
-				// log.Println("🔊 Volume Monitor stopped (external stop)")
-			case <-s.stopChan:
-				// log.Println("🔊 Volume Monitor stopped (internal stop)")
-			}
-			if cmd.Process != nil {
-				cmd.Process.Kill()
-			}
-		}()
-
-		for scanner.Scan() {
-			line := scanner.Text()
-
-			if strings.Contains(line, "Event 'change' on sink") || strings.Contains(line, "Event 'new' on sink") {
-
-				time.Sleep(50 * time.Millisecond)
-				s.fetchAndPublish(bus)
-			}
-		}
-
-		if err := scanner.Err(); err != nil {
-			// log.Printf("⚠️ pactl subscribe scanner error: %v", err)
+	go func() {
+		select {
+		case <-stopChan:
+			// log.Println("🔊 Volume Monitor stopped (external stop)")
+		case <-s.stopChan:
+			// log.Println("🔊 Volume Monitor stopped (internal stop)")
 		}
+		unsubscribe()
+		unsubscribeStreams()
 	}()
 
 	return nil
@@ -96,19 +68,15 @@ func (s *VolumeSource) fetchAndPublish(bus core.Bus) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	sinkCmd := exec.Command("sh", "-c", "LANG=C pactl get-default-sink")
-	sinkOut, err := sinkCmd.Output()
+	info, err := s.backend.ServerInfo()
 	if err != nil {
-		// No default sink available (e.g., all players are off)
-		// This is normal and shouldn't be treated as an error
-		if !s.initialized {
-			// log.Printf("⚠️ No default sink available yet (this is normal when no audio is playing)")
-		}
+		// Sound server not reachable yet; the backend retries in the background
 		return
 	}
-	currentSink := strings.TrimSpace(string(sinkOut))
+	currentSink := info.DefaultSink
 
-	// Check if sink name is empty (shouldn't happen, but be safe)
+	// No default sink available (e.g., all outputs are gone)
+	// This is normal and shouldn't be treated as an error
 	if currentSink == "" {
 		if !s.initialized {
 			// log.Printf("⚠️ Default sink name is empty")
@@ -116,43 +84,23 @@ func (s *VolumeSource) fetchAndPublish(bus core.Bus) {
 		return
 	}
 
-	muteCmd := exec.Command("sh", "-c", "LANG=C pactl get-sink-mute @DEFAULT_SINK@")
-	muteOut, err := muteCmd.Output()
+	sink, err := s.backend.Sink(currentSink)
 	if err != nil {
-		// Sink might have disappeared between get-default-sink and this call
+		// Sink might have disappeared between the server info and this call
 		if !s.initialized {
-			// log.Printf("⚠️ Unable to get mute status (sink may have disappeared)")
+			// log.Printf("⚠️ Unable to get sink state (sink may have disappeared)")
 		}
 		return
 	}
-	isMuted := strings.Contains(string(muteOut), "yes")
-
-	volCmd := exec.Command("sh", "-c", "LANG=C pactl get-sink-volume @DEFAULT_SINK@")
-	volOut, err := volCmd.Output()
-	if err != nil {
-		// Sink might have disappeared between get-default-sink and this call
-		if !s.initialized {
-			// log.Printf("⚠️ Unable to get volume level (sink may have disappeared)")
-		}
-		return
-	}
-
-	matches := s.volumeRegex.FindStringSubmatch(string(volOut))
-
-	level := 0
-	if len(matches) > 1 {
-		if parsedLevel, err := strconv.Atoi(matches[1]); err == nil {
-			level = parsedLevel
-		} else {
-			// log.Printf("⚠️ Failed to parse volume level: %v", err)
-		}
-	}
+	isMuted := sink.Muted
+	level := sink.Level()
 
 	var eventType core.EventType
 
 	if !s.initialized {
 
 		s.lastSink = currentSink
+		s.lastPort = sink.ActivePort
 		s.lastLevel = level
 		s.lastMuted = isMuted
 		s.initialized = true
@@ -161,12 +109,17 @@ func (s *VolumeSource) fetchAndPublish(bus core.Bus) {
 		return
 	}
 
-	if s.lastSink != currentSink {
-		// log.Printf("🔌 Sink Switched: %s -> %s (Ignored Volume Event)", s.lastSink, currentSink)
+	// A new output (or a jack changing the active port) reports its own
+	// volume, so it replaces the volume event instead of adding to it
+	if s.lastSink != currentSink || s.lastPort != sink.ActivePort {
+		// log.Printf("🔌 Output Switched: %s -> %s (%s)", s.lastSink, currentSink, sink.ActivePort)
 
 		s.lastSink = currentSink
+		s.lastPort = sink.ActivePort
 		s.lastLevel = level
 		s.lastMuted = isMuted
+
+		bus.Publish(newOutputEvent(&sink))
 		return
 	}
 
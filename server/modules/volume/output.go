@@ -0,0 +1,74 @@
+package volume
+
+import (
+	"dynamic-island-server/core"
+	"dynamic-island-server/modules/audio"
+	"strings"
+)
+
+const (
+	FormFactorHeadphone = "headphone"
+	FormFactorSpeaker   = "speaker"
+	FormFactorHDMI      = "hdmi"
+	FormFactorBluetooth = "bluetooth"
+)
+
+// formFactor classifies a sink from its device properties and active port,
+// falling back to speaker for anything unrecognised.
+func formFactor(sink *audio.Sink) string {
+	props := sink.Props
+	if props["device.bus"] == "bluetooth" || props["device.api"] == "bluez5" ||
+		strings.HasPrefix(sink.Name, "bluez_") {
+		return FormFactorBluetooth
+	}
+
+	port := strings.ToLower(sink.ActivePort)
+	switch {
+	case strings.Contains(port, "hdmi"), strings.Contains(port, "displayport"),
+		strings.Contains(strings.ToLower(sink.Name), "hdmi"):
+		return FormFactorHDMI
+	case strings.Contains(port, "headphone"), strings.Contains(port, "headset"):
+		return FormFactorHeadphone
+	}
+
+	switch props["device.form_factor"] {
+	case "headphone", "headset", "hands-free":
+		return FormFactorHeadphone
+	case "tv":
+		return FormFactorHDMI
+	}
+	return FormFactorSpeaker
+}
+
+func outputIcon(formFactor string) string {
+	switch formFactor {
+	case FormFactorHeadphone:
+		return "audio-headphones-symbolic"
+	case FormFactorHDMI:
+		return "video-display-symbolic"
+	case FormFactorBluetooth:
+		return "bluetooth-active-symbolic"
+	default:
+		return "audio-speakers-symbolic"
+	}
+}
+
+// outputDescription prefers the active port's label when the sink has more
+// than one, so "Headphones" shows instead of the sound card name.
+func outputDescription(sink *audio.Sink) string {
+	return portDescription(sink.Ports, sink.ActivePort, sink.Description)
+}
+
+func newOutputEvent(sink *audio.Sink) *core.Event {
+	ff := formFactor(sink)
+
+	event := core.NewEvent(core.EventAudioOutputChanged, "volume", 0)
+	event.Metadata["sink"] = sink.Name
+	event.Metadata["description"] = outputDescription(sink)
+	event.Metadata["port"] = sink.ActivePort
+	event.Metadata["form_factor"] = ff
+	event.Metadata["icon"] = outputIcon(ff)
+	event.Metadata["level"] = sink.Level()
+	event.Metadata["muted"] = sink.Muted
+	return event
+}
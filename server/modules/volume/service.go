@@ -1,40 +1,156 @@
 package volume
 
 import (
+	"dynamic-island-server/core"
+	"dynamic-island-server/modules/audio"
 	"fmt"
-	"os/exec"
+	"sync"
 )
 
-type VolumeService struct{}
+const (
+	defaultVolumeStep  = 5
+	normalMaxVolume    = 100
+	amplifiedMaxVolume = 150
+)
+
+type VolumeService struct {
+	backend   *audio.Backend
+	maxVolume int
+
+	// mu serializes read-modify-write volume changes so that quick
+	// VolumeUp/VolumeDown calls each build on the previous result.
+	mu sync.Mutex
 
-func NewVolumeService() *VolumeService {
-	return &VolumeService{}
+	amplify amplifySetting
 }
 
-func (s *VolumeService) SetVolume(level int32) error {
+func NewVolumeService(backend *audio.Backend, cfg core.VolumeConfig) *VolumeService {
+	return &VolumeService{
+		backend:   backend,
+		maxVolume: cfg.MaxVolume,
+	}
+}
+
+// MaxVolume returns the configured cap, or follows GNOME's
+// allow-volume-above-100-percent when none is configured.
+func (s *VolumeService) MaxVolume() int {
+	if s.maxVolume > 0 {
+		if s.maxVolume < normalMaxVolume {
+			return normalMaxVolume
+		}
+		if s.maxVolume > amplifiedMaxVolume {
+			return amplifiedMaxVolume
+		}
+		return s.maxVolume
+	}
+
+	if s.amplify.get() {
+		return amplifiedMaxVolume
+	}
+	return normalMaxVolume
+}
 
+func (s *VolumeService) clamp(level int) int {
 	if level < 0 {
-		level = 0
+		return 0
 	}
-	if level > 120 {
-		level = 120
+	if max := s.MaxVolume(); level > max {
+		return max
 	}
+	return level
+}
+
+func (s *VolumeService) SetVolume(level int32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	// log.Printf("🎚️ SetVolume called: %d%%", level)
 
-	cmd := exec.Command("pactl", "set-sink-volume", "@DEFAULT_SINK@", fmt.Sprintf("%d%%", level))
-	if err := cmd.Run(); err != nil {
+	sink, err := s.backend.Sink(audio.DefaultSink)
+	if err != nil {
+		return fmt.Errorf("failed to set volume: %v", err)
+	}
+	if err := s.backend.SetSinkVolume(sink.Name, audio.ScaleVolume(sink.Volume, s.clamp(int(level)))); err != nil {
 		// log.Printf("Error setting volume: %v", err)
 		return fmt.Errorf("failed to set volume: %v", err)
 	}
 	return nil
 }
 
+// VolumeUp raises the default output by step percent (5 when step <= 0)
+// and returns the new level. A level already above the cap is kept.
+func (s *VolumeService) VolumeUp(step int32) (int32, error) {
+	if step <= 0 {
+		step = defaultVolumeStep
+	}
+	return s.stepVolume(int(step))
+}
+
+// VolumeDown lowers the default output by step percent (5 when step <= 0)
+// and returns the new level.
+func (s *VolumeService) VolumeDown(step int32) (int32, error) {
+	if step <= 0 {
+		step = defaultVolumeStep
+	}
+	return s.stepVolume(-int(step))
+}
+
+func (s *VolumeService) stepVolume(delta int) (int32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sink, err := s.backend.Sink(audio.DefaultSink)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read volume: %v", err)
+	}
+
+	current := sink.Level()
+	target := s.clamp(current + delta)
+	if delta > 0 && target < current {
+		// Raised above the cap elsewhere; stepping up must not lower it
+		target = current
+	}
+	if target == current {
+		return int32(current), nil
+	}
+
+	if err := s.backend.SetSinkVolume(sink.Name, audio.ScaleVolume(sink.Volume, target)); err != nil {
+		return 0, fmt.Errorf("failed to set volume: %v", err)
+	}
+	return int32(target), nil
+}
+
+// SetVolumeBalance sets the left/right balance of the default output from
+// -1 (left only) to 1 (right only) without changing its level.
+func (s *VolumeService) SetVolumeBalance(balance float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sink, err := s.backend.Sink(audio.DefaultSink)
+	if err != nil {
+		return fmt.Errorf("failed to read volume: %v", err)
+	}
+	vols, err := audio.SetBalance(sink.Volume, sink.ChannelMap, balance)
+	if err != nil {
+		return err
+	}
+	if err := s.backend.SetSinkVolume(sink.Name, vols); err != nil {
+		return fmt.Errorf("failed to set balance: %v", err)
+	}
+	return nil
+}
+
 func (s *VolumeService) ToggleMute() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// log.Println("🔇 ToggleMute called")
 
-	cmd := exec.Command("pactl", "set-sink-mute", "@DEFAULT_SINK@", "toggle")
-	if err := cmd.Run(); err != nil {
+	sink, err := s.backend.Sink(audio.DefaultSink)
+	if err != nil {
+		return fmt.Errorf("failed to toggle mute: %v", err)
+	}
+	if err := s.backend.SetSinkMute(sink.Name, !sink.Muted); err != nil {
 		// log.Printf("Error toggling mute: %v", err)
 		return fmt.Errorf("failed to toggle mute: %v", err)
 	}
@@ -0,0 +1,79 @@
+package volume
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	portalDest         = "org.freedesktop.portal.Desktop"
+	portalPath         = "/org/freedesktop/portal/desktop"
+	portalSettingsIntf = "org.freedesktop.portal.Settings"
+
+	soundSchema = "org.gnome.desktop.sound"
+	amplifyKey  = "allow-volume-above-100-percent"
+)
+
+// amplifySetting follows GNOME's allow-volume-above-100-percent through the
+// settings portal: it is read once and then kept current from the
+// SettingChanged signal.
+type amplifySetting struct {
+	once    sync.Once
+	allowed atomic.Bool
+}
+
+func (a *amplifySetting) get() bool {
+	a.once.Do(a.watch)
+	return a.allowed.Load()
+}
+
+func (a *amplifySetting) watch() {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		// log.Printf("⚠️ Failed to read %s: %v", amplifyKey, err)
+		return
+	}
+
+	matchRule := "type='signal',interface='" + portalSettingsIntf + "',member='SettingChanged',arg0='" + soundSchema + "',arg1='" + amplifyKey + "'"
+	if call := conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, matchRule); call.Err != nil {
+		conn.Close()
+		return
+	}
+	signals := make(chan *dbus.Signal, 4)
+	conn.Signal(signals)
+
+	a.allowed.Store(readAmplify(conn))
+
+	go func() {
+		defer conn.Close()
+		for signal := range signals {
+			if signal.Name != portalSettingsIntf+".SettingChanged" || len(signal.Body) < 3 {
+				continue
+			}
+			if value, ok := signal.Body[2].(dbus.Variant); ok {
+				allowed, _ := value.Value().(bool)
+				a.allowed.Store(allowed)
+			}
+		}
+	}()
+}
+
+func readAmplify(conn *dbus.Conn) bool {
+	portal := conn.Object(portalDest, portalPath)
+
+	var value dbus.Variant
+	if err := portal.Call(portalSettingsIntf+".ReadOne", 0, soundSchema, amplifyKey).Store(&value); err != nil {
+		// Read, the only method of portals before version 2, wraps the
+		// value in a second variant
+		if err := portal.Call(portalSettingsIntf+".Read", 0, soundSchema, amplifyKey).Store(&value); err != nil {
+			return false
+		}
+		if inner, ok := value.Value().(dbus.Variant); ok {
+			value = inner
+		}
+	}
+	allowed, _ := value.Value().(bool)
+	return allowed
+}
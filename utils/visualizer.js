@@ -1,6 +1,9 @@
 const St = imports.gi.St;
 const Clutter = imports.gi.Clutter;
 
+// How long a reported level or spectrum keeps driving the bars
+const LEVEL_TIMEOUT_MS = 500;
+
 /**
  * MirroredVisualizer - A mirrored audio visualizer component
  * Creates a symmetric top-bottom visualizer with animated bars
@@ -17,6 +20,10 @@ var MirroredVisualizer = class MirroredVisualizer {
         
         this._visualizerBars = [];
         this._visualizerAnimation = null;
+        this._level = 0;
+        this._levelTime = 0;
+        this._bands = null;
+        this._bandsTime = 0;
         this._currentColor = this._generateRandomColor();
         
         this._buildVisualizer();
@@ -148,15 +155,26 @@ var MirroredVisualizer = class MirroredVisualizer {
                 }
 
                 let height = state.base + state.offset;
+                const indexInRow = i % this._barCount;
+                const hasBands = this._bands && Date.now() - this._bandsTime < LEVEL_TIMEOUT_MS;
+
+                if (hasBands) {
+                    // One band per bar, lowest frequencies on the left
+                    const band = Math.floor(indexInRow * this._bands.length / this._barCount);
+                    height = 2 + Math.round(this._bands[band] * (this._rowHeight - 2));
+                } else if (Date.now() - this._levelTime < LEVEL_TIMEOUT_MS) {
+                    // Scale the pattern by the real level while one is arriving
+                    const scale = 0.3 + 0.7 * Math.sqrt(this._level);
+                    height = Math.max(2, Math.round(state.base * scale) + state.offset);
+                }
 
                 // Giữ form: chỉ check trong cùng hàng
-                const indexInRow = i % this._barCount;
-                if (indexInRow > 0) {
+                if (indexInRow > 0 && !hasBands) {
                     const prevBar = this._visualizerBars[i - 1];
                     height = Math.max(height, prevBar.height - 2);
                 }
 
-                const opacity = 0.7 + (height / 10) * 0.3;
+                const opacity = Math.min(1, 0.7 + (height / 10) * 0.3);
                 const borderRadius = i < this._barCount ? '1.5px 1.5px 0px 0px' : '0px 0px 1.5px 1.5px';
 
                 bar.set_height(height);
@@ -171,6 +189,29 @@ var MirroredVisualizer = class MirroredVisualizer {
         }, this._animationSpeed);
     }
 
+    /**
+     * Feed a real audio level (0-1); falls back to the idle animation when
+     * levels stop arriving
+     * @param {number} level - Peak level
+     */
+    setLevel(level) {
+        this._level = Math.max(0, Math.min(1, level));
+        this._levelTime = Date.now();
+    }
+
+    /**
+     * Feed spectrum band levels (0-1, low to high frequency); falls back to
+     * the idle animation when frames stop arriving
+     * @param {number[]} bands - Band levels
+     */
+    setBands(bands) {
+        if (!bands || bands.length === 0) {
+            return;
+        }
+        this._bands = bands;
+        this._bandsTime = Date.now();
+    }
+
     /**
      * Stop visualizer animation
      */
@@ -146,8 +146,11 @@ var CameraView = class CameraView {
         }
 
         this._appName = cameraInfo.appName || 'Camera';
-        if (this.statusLabel) this.statusLabel.set_text('Camera');
-        if (this.detailsLabel) this.detailsLabel.set_text(this._appName);
+        // e.g. "HD Pro Webcam C920" / "in use by zoom"
+        if (this.statusLabel) this.statusLabel.set_text(cameraInfo.cameraName || 'Camera');
+        if (this.detailsLabel) {
+            this.detailsLabel.set_text(cameraInfo.cameraName ? `in use by ${this._appName}` : this._appName);
+        }
         
         // Start visualizer animation
         this._visualizer.start();
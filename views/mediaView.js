@@ -22,6 +22,30 @@ var MediaView = class MediaView {
         this._buildCompactView();
         this._buildExpandedView();
         this._buildMinimalView();
+        this._connectSpectrum();
+    }
+
+    /**
+     * Feed the output spectrum to both visualizers, requesting frames only
+     * while one of them is on screen
+     */
+    _connectSpectrum() {
+        this._mediaManager.addSpectrumCallback((bands) => {
+            if (this._visualizer) {
+                this._visualizer.setBands(bands);
+            }
+            if (this._secondaryVisualizer) {
+                this._secondaryVisualizer.setBands(bands);
+            }
+        });
+
+        const updateActive = () => {
+            this._mediaManager.setSpectrumActive(
+                this.compactContainer.mapped || this.secondaryContainer.mapped
+            );
+        };
+        this._compactMappedId = this.compactContainer.connect('notify::mapped', updateActive);
+        this._secondaryMappedId = this.secondaryContainer.connect('notify::mapped', updateActive);
     }
 
     _buildMinimalView() {
@@ -835,6 +859,16 @@ var MediaView = class MediaView {
     }
 
     destroy() {
+        if (this._compactMappedId) {
+            this.compactContainer.disconnect(this._compactMappedId);
+            this._compactMappedId = null;
+        }
+        if (this._secondaryMappedId) {
+            this.secondaryContainer.disconnect(this._secondaryMappedId);
+            this._secondaryMappedId = null;
+        }
+        this._mediaManager.setSpectrumActive(false);
+
         // Stop and destroy visualizer
         if (this._visualizer) {
             this._visualizer.destroy();
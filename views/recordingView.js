@@ -159,6 +159,14 @@ var RecordingView = class RecordingView {
         this._startIconAnimation();
     }
 
+    /**
+     * Drive the visualizer with the real microphone peak (0-1)
+     * @param {number} peak - Input peak reported by the server
+     */
+    setLevel(peak) {
+        this._visualizer.setLevel(peak);
+    }
+
     _startIconAnimation() {
         if (this._iconAnimationTimer) {
             return; // Already animating